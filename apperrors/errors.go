@@ -0,0 +1,125 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code 错误码目录中的一项，数字码与名称保持稳定，客户端可据此判断错误类型
+type Code struct {
+	Num    int    // 数字错误码，对应响应中的 code 字段
+	Name   string // 机器可读的错误名称
+	Status int    // HTTP 状态码
+	Title  string // 默认描述
+}
+
+// 错误码目录
+var (
+	CodeValidation            = Code{1001, "validation_failed", http.StatusBadRequest, "Validation failed"}
+	CodeInternal              = Code{1002, "internal_error", http.StatusInternalServerError, "Internal server error"}
+	CodeBatchAborted          = Code{1003, "batch_aborted", http.StatusConflict, "Batch aborted"}
+	CodeVersionConflict       = Code{1004, "version_conflict", http.StatusPreconditionFailed, "Task has been modified by another request"}
+	CodeNotFound              = Code{1005, "not_found", http.StatusNotFound, "Resource not found"}
+	CodeIdempotencyKeyReused  = Code{1006, "idempotency_key_reused", http.StatusUnprocessableEntity, "Idempotency key was already used with a different request"}
+	CodeIdempotencyInProgress = Code{1007, "idempotency_in_progress", http.StatusConflict, "A request with this idempotency key is still in progress"}
+	CodeConflict              = Code{1008, "conflict", http.StatusConflict, "Conflict with the current state of the resource"}
+	CodeForbidden             = Code{1009, "forbidden", http.StatusForbidden, "Forbidden"}
+	CodeQueryTooComplex       = Code{1010, "query_too_complex", http.StatusBadRequest, "Query exceeds the depth or complexity limit"}
+	CodeUndoExpired           = Code{1011, "undo_expired", http.StatusGone, "Operation can no longer be undone"}
+	CodePayloadTooLarge       = Code{1012, "payload_too_large", http.StatusRequestEntityTooLarge, "Request body is too large"}
+)
+
+// Codes 错误码目录中的全部错误码，新增错误码时需同时加入
+var Codes = []Code{
+	CodeValidation, CodeInternal, CodeBatchAborted, CodeVersionConflict, CodeNotFound, CodeIdempotencyKeyReused,
+	CodeIdempotencyInProgress, CodeConflict, CodeForbidden, CodeQueryTooComplex, CodeUndoExpired, CodePayloadTooLarge,
+}
+
+// Error 应用错误，携带错误码、字段级错误和附加数据
+type Error struct {
+	Code    Code
+	Message string            // 面向客户端的错误说明
+	Fields  map[string]string // 字段级错误，key 为字段名
+	Data    interface{}       // 附加数据，如版本冲突时服务端当前的任务
+	Err     error             // 原始错误
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Err.Error() != e.Message {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithFields 返回设置了字段级错误的副本，不修改 e，e 可能是多个请求共享的错误
+func (e *Error) WithFields(fields map[string]string) *Error {
+	c := *e
+	c.Fields = fields
+	return &c
+}
+
+// WithData 返回设置了附加数据的副本，不修改 e，e 可能是多个请求共享的错误
+func (e *Error) WithData(data interface{}) *Error {
+	c := *e
+	c.Data = data
+	return &c
+}
+
+// New 创建应用错误，msg 为空时使用错误码的默认描述
+func New(code Code, msg string) *Error {
+	if msg == "" {
+		msg = code.Title
+	}
+	return &Error{Code: code, Message: msg}
+}
+
+// Newf 使用格式化字符串创建应用错误
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap 使用指定错误码包装原始错误，msg 为空时使用原始错误的描述
+func Wrap(code Code, err error, msg string) *Error {
+	if msg == "" {
+		if err != nil {
+			msg = err.Error()
+		} else {
+			msg = code.Title
+		}
+	}
+	return &Error{Code: code, Message: msg, Err: err}
+}
+
+// Translator 将其他包定义的错误转换为应用错误，无法识别时返回 nil
+type Translator func(err error) *Error
+
+var translators []Translator
+
+// RegisterTranslator 注册错误转换函数，通常在 init 中调用
+func RegisterTranslator(t Translator) {
+	translators = append(translators, t)
+}
+
+// From 将任意错误转换为应用错误
+// 错误链中已有 *Error 时直接使用，其次依次尝试已注册的转换函数，都无法识别时视为内部错误
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	for _, translate := range translators {
+		if appErr = translate(err); appErr != nil {
+			return appErr
+		}
+	}
+	return Wrap(CodeInternal, err, CodeInternal.Title)
+}
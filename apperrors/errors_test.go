@@ -0,0 +1,53 @@
+package apperrors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// TestCodesListsCatalog 错误码目录中定义的每个错误码都在 Codes 中，且数字码和名称不重复
+func TestCodesListsCatalog(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := map[int]bool{}
+	for _, code := range Codes {
+		listed[code.Num] = true
+	}
+
+	// 按数字码查找目录中以 Code{...} 定义的错误码
+	defined := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		lit, ok := node.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if ident, isIdent := lit.Type.(*ast.Ident); !isIdent || ident.Name != "Code" || len(lit.Elts) == 0 {
+			return true
+		}
+		num, err := strconv.Atoi(lit.Elts[0].(*ast.BasicLit).Value)
+		if err != nil {
+			t.Fatalf("unexpected code literal: %v", err)
+		}
+		defined++
+		if !listed[num] {
+			t.Errorf("code %d is not listed in Codes", num)
+		}
+		return true
+	})
+	if defined != len(Codes) {
+		t.Errorf("catalog defines %d codes, Codes lists %d", defined, len(Codes))
+	}
+
+	nums, names := map[int]bool{}, map[string]bool{}
+	for _, code := range Codes {
+		if nums[code.Num] || names[code.Name] {
+			t.Errorf("duplicate code %d %s", code.Num, code.Name)
+		}
+		nums[code.Num], names[code.Name] = true, true
+	}
+}
@@ -0,0 +1,54 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation 将请求绑定或校验错误转换为校验错误，并尽量提取字段级错误
+func Validation(err error) *Error {
+	appErr := Wrap(CodeValidation, err, "")
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make(map[string]string, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields[fieldErr.Field()] = validationMessage(fieldErr)
+		}
+		appErr.Message = CodeValidation.Title
+		appErr.Fields = fields
+	case errors.As(err, &typeErr):
+		appErr.Message = CodeValidation.Title
+		appErr.Fields = map[string]string{typeErr.Field: fmt.Sprintf("must be of type %s", typeErr.Type)}
+	}
+	return appErr
+}
+
+// InvalidField 创建单个字段的校验错误
+func InvalidField(field, msg string) *Error {
+	return New(CodeValidation, CodeValidation.Title).WithFields(map[string]string{field: msg})
+}
+
+// validationMessage 将校验规则转换为可读的错误说明
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + fieldErr.Param()
+	case "gte":
+		return "must be greater than or equal to " + fieldErr.Param()
+	case "lte":
+		return "must be less than or equal to " + fieldErr.Param()
+	case "min":
+		return "must have at least " + fieldErr.Param() + " item(s)"
+	case "max":
+		return "must have at most " + fieldErr.Param() + " item(s)"
+	default:
+		return "failed on the '" + fieldErr.Tag() + "' rule"
+	}
+}
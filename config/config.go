@@ -1,15 +1,21 @@
 package config
 
 import (
+	"E-Todo/storage"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 var DB *gorm.DB
 
+// Blobs 附件内容的存储
+var Blobs storage.BlobStore
+
 func InitDB() {
 	err := godotenv.Load() // 加载 .env 文件
 	if err != nil {
@@ -23,3 +29,136 @@ func InitDB() {
 	}
 	log.Println("Database connection established")
 }
+
+// InitBlobStore 根据 BLOB_STORE 初始化附件存储：local（默认，保存在 BLOB_DIR 目录）或 s3（S3 兼容存储）
+func InitBlobStore() {
+	var err error
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		Blobs, err = storage.NewLocalStore(dir)
+	case "s3":
+		Blobs, err = storage.NewS3Store(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		})
+	default:
+		log.Fatalf("Invalid value for BLOB_STORE: %q", kind)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize blob store: %v", err)
+	}
+}
+
+// AttachmentMaxSize 单个附件的最大字节数 (ATTACHMENT_MAX_SIZE_MB)
+func AttachmentMaxSize() int64 {
+	return int64(getEnvInt("ATTACHMENT_MAX_SIZE_MB", 25)) << 20
+}
+
+// TrashRetentionDays 回收站保留天数，软删除超过该天数的任务会被后台任务彻底删除，0 表示不自动清理
+func TrashRetentionDays() int {
+	return getEnvInt("TRASH_RETENTION_DAYS", 30)
+}
+
+// IdempotencyTTL 幂等键的保存时长，在此期间使用相同幂等键的重试会重放首次响应
+func IdempotencyTTL() time.Duration {
+	return time.Duration(getEnvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour
+}
+
+// TaskEventRetentionDays 任务变更事件的保留天数，超过该天数的事件不再支持续传，0 表示不清理
+func TaskEventRetentionDays() int {
+	return getEnvInt("TASK_EVENT_RETENTION_DAYS", 7)
+}
+
+// UndoWindow 任务操作可撤销的时长 (UNDO_WINDOW_MINUTES)，超过后操作及其快照会被清理
+func UndoWindow() time.Duration {
+	return time.Duration(getEnvInt("UNDO_WINDOW_MINUTES", 60)) * time.Minute
+}
+
+// LogTaskEvents 是否将发布的任务变更事件写入日志 (LOG_TASK_EVENTS=true)
+func LogTaskEvents() bool {
+	return os.Getenv("LOG_TASK_EVENTS") == "true"
+}
+
+// ChecklistRequiredForCompletion 是否要求勾选全部检查项后才能完成任务 (CHECKLIST_REQUIRED_FOR_COMPLETION)
+func ChecklistRequiredForCompletion() bool {
+	return os.Getenv("CHECKLIST_REQUIRED_FOR_COMPLETION") == "true"
+}
+
+// DueDateReminders 是否为新建的任务自动添加截止时提醒 (DUE_DATE_REMINDERS=false 时关闭)
+func DueDateReminders() bool {
+	return os.Getenv("DUE_DATE_REMINDERS") != "false"
+}
+
+// ReminderMaxAttempts 提醒发送的最大尝试次数，超过后不再重试
+func ReminderMaxAttempts() int {
+	return getEnvInt("REMINDER_MAX_ATTEMPTS", 5)
+}
+
+// LogReminders 是否将发送的提醒写入日志 (LOG_REMINDERS=true)
+func LogReminders() bool {
+	return os.Getenv("LOG_REMINDERS") == "true"
+}
+
+// BulkUpdateMaxTasks 一次按条件批量更新最多可匹配的任务数 (BULK_UPDATE_MAX_TASKS)，超过时拒绝请求
+func BulkUpdateMaxTasks() int {
+	return getEnvInt("BULK_UPDATE_MAX_TASKS", 1000)
+}
+
+// WebhookMaxAttempts Webhook 投递的最大尝试次数，超过后不再自动重试
+func WebhookMaxAttempts() int {
+	return getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+}
+
+// GRPCAddr gRPC 服务的监听地址，为空时不启动 gRPC 服务
+func GRPCAddr() string {
+	if addr, ok := os.LookupEnv("GRPC_ADDR"); ok {
+		return addr
+	}
+	return ":9090"
+}
+
+// GraphQLMaxDepth GraphQL 查询允许的最大嵌套深度
+func GraphQLMaxDepth() int {
+	return getEnvInt("GRAPHQL_MAX_DEPTH", 8)
+}
+
+// GraphQLMaxComplexity GraphQL 查询允许的最大复杂度，列表字段的子字段按 limit 倍计算
+func GraphQLMaxComplexity() int {
+	return getEnvInt("GRAPHQL_MAX_COMPLEXITY", 2000)
+}
+
+// LegacyAPISunset 未带版本号的旧路由的停用日期 (LEGACY_API_SUNSET，格式：yyyy-MM-dd)，未设置时返回零值
+func LegacyAPISunset() time.Time {
+	value := os.Getenv("LEGACY_API_SUNSET")
+	if value == "" {
+		return time.Time{}
+	}
+	sunset, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Printf("Invalid value for LEGACY_API_SUNSET: %q, ignoring", value)
+		return time.Time{}
+	}
+	return sunset
+}
+
+// getEnvInt 读取整数类型的环境变量，未设置或格式错误时返回默认值
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %d", key, value, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/config"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// multipartOverhead 表单中除文件内容外允许的额外字节数（分隔符、字段头等）
+const multipartOverhead = 1 << 20
+
+// UploadAttachment 上传任务附件，文件放在 multipart/form-data 表单的 file 字段中
+func UploadAttachment(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AttachmentMaxSize()+multipartOverhead)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			utils.Error(c, apperrors.Newf(apperrors.CodePayloadTooLarge, "attachment exceeds the limit of %d bytes", config.AttachmentMaxSize()))
+		case errors.Is(err, http.ErrMissingFile):
+			utils.Error(c, apperrors.InvalidField("file", "file is required"))
+		default:
+			utils.Error(c, apperrors.InvalidField("file", "invalid multipart form"))
+		}
+		return
+	}
+
+	attachment, err := services.UploadAttachment(c.Request.Context(), taskID, file)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Created(c, attachment, "Attachment uploaded successfully")
+}
+
+// FetchAttachments 获取任务的附件
+func FetchAttachments(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	attachments, err := services.FetchAttachments(taskID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, attachments, "Attachments fetched successfully")
+}
+
+// GetAttachment 获取附件元数据
+func GetAttachment(c *gin.Context) {
+	taskID, attachmentID, err := getAttachmentIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	attachment, err := services.GetAttachment(taskID, attachmentID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, attachment, "Attachment fetched successfully")
+}
+
+// DownloadAttachment 下载附件内容，以流的形式返回，不在内存中缓存整个文件
+func DownloadAttachment(c *gin.Context) {
+	taskID, attachmentID, err := getAttachmentIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	content, err := services.OpenAttachment(c.Request.Context(), taskID, attachmentID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+	defer content.Body.Close()
+
+	attachment := content.Attachment
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, content.Body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}),
+		"ETag":                   strconv.Quote(attachment.Checksum),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment 删除附件
+func DeleteAttachment(c *gin.Context) {
+	taskID, attachmentID, err := getAttachmentIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if err = services.DeleteAttachment(c.Request.Context(), taskID, attachmentID); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, "Attachment deleted successfully")
+}
+
+// getAttachmentIDsFromParam 从 URL 参数中获取任务 ID 和附件 ID
+func getAttachmentIDsFromParam(c *gin.Context) (uint, uint, error) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil || attachmentID <= 0 {
+		return 0, 0, apperrors.InvalidField("attachment_id", "invalid attachment ID")
+	}
+	return taskID, uint(attachmentID), nil
+}
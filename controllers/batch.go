@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ExecuteBatch 在一个事务中执行一组混合操作
+func ExecuteBatch(c *gin.Context) {
+	var req dto.BatchOperationsReq
+
+	// 绑定 JSON 数据到 BatchOperationsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	resp, err := services.ExecuteBatch(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, apperrors.From(err).WithData(resp))
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, resp, "Batch executed successfully")
+}
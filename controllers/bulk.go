@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkUpdateTasks 按条件批量更新任务字段
+func BulkUpdateTasks(c *gin.Context) {
+	var req dto.BulkUpdateTasksReq
+
+	// 绑定 JSON 数据到 BulkUpdateTasksReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	resp, err := services.BulkUpdateTasks(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	if req.DryRun {
+		utils.Success(c, resp, "Bulk update dry run completed")
+		return
+	}
+	utils.Success(c, resp, "Tasks bulk updated successfully")
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// FetchChecklist 获取任务的检查清单
+func FetchChecklist(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	checklist, err := services.FetchChecklist(taskID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, checklist, "Checklist fetched successfully")
+}
+
+// AddChecklistItem 添加检查项
+func AddChecklistItem(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.CreateChecklistItemReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	item, err := services.AddChecklistItem(c.Request.Context(), taskID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Created(c, item, "Checklist item created successfully")
+}
+
+// UpdateChecklistItem 修改、勾选或取消勾选检查项
+func UpdateChecklistItem(c *gin.Context) {
+	taskID, itemID, err := getChecklistItemIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateChecklistItemReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	item, err := services.UpdateChecklistItem(c.Request.Context(), taskID, itemID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, item, "Checklist item updated successfully")
+}
+
+// ReorderChecklist 重新排列检查项
+func ReorderChecklist(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.ReorderChecklistReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	checklist, err := services.ReorderChecklist(c.Request.Context(), taskID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, checklist, "Checklist reordered successfully")
+}
+
+// DeleteChecklistItem 删除检查项
+func DeleteChecklistItem(c *gin.Context) {
+	taskID, itemID, err := getChecklistItemIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if err = services.DeleteChecklistItem(c.Request.Context(), taskID, itemID); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, "Checklist item deleted successfully")
+}
+
+// getChecklistItemIDsFromParam 从 URL 参数中获取任务 ID 和检查项 ID
+func getChecklistItemIDsFromParam(c *gin.Context) (uint, uint, error) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil || itemID <= 0 {
+		return 0, 0, apperrors.InvalidField("item_id", "invalid checklist item ID")
+	}
+	return taskID, uint(itemID), nil
+}
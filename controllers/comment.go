@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// CreateComment 在任务下发表评论或回复
+func CreateComment(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.CreateCommentReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	comment, err := services.CreateComment(c.Request.Context(), taskID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Created(c, comment, "Comment created successfully")
+}
+
+// FetchComments 获取任务的评论
+func FetchComments(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.FetchCommentsReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	comments, err := services.FetchComments(taskID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, comments, "Comments fetched successfully")
+}
+
+// UpdateComment 修改评论
+func UpdateComment(c *gin.Context) {
+	taskID, commentID, err := getCommentIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateCommentReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	comment, err := services.UpdateComment(c.Request.Context(), taskID, commentID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, comment, "Comment updated successfully")
+}
+
+// DeleteComment 删除评论及其回复
+func DeleteComment(c *gin.Context) {
+	taskID, commentID, err := getCommentIDsFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if err = services.DeleteComment(c.Request.Context(), taskID, commentID); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, "Comment deleted successfully")
+}
+
+// getCommentIDsFromParam 从 URL 参数中获取任务 ID 和评论 ID
+func getCommentIDsFromParam(c *gin.Context) (uint, uint, error) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil || commentID <= 0 {
+		return 0, 0, apperrors.InvalidField("comment_id", "invalid comment ID")
+	}
+	return taskID, uint(commentID), nil
+}
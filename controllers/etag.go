@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// setETag 根据任务版本号设置 ETag 响应头
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// getIfMatchVersion 从 If-Match 请求头解析期望的任务版本，未提供或为 * 时返回 0（不校验）
+func getIfMatchVersion(c *gin.Context) (uint, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	version, err := strconv.ParseUint(strings.Trim(value, `"`), 10, 64)
+	if err != nil || version == 0 {
+		return 0, apperrors.InvalidField("If-Match", "must be a quoted version number or *")
+	}
+	return uint(version), nil
+}
+
+// failTask 返回单个任务操作的错误，版本冲突时同时返回当前版本的 ETag
+func failTask(c *gin.Context, err error) {
+	if current, ok := services.ConflictingTask(err); ok {
+		setETag(c, current.Version)
+	}
+	utils.Error(c, err)
+}
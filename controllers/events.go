@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseHeartbeatInterval 心跳间隔，防止代理因连接空闲而断开
+const sseHeartbeatInterval = 15 * time.Second
+
+// TaskEvents 以 Server-Sent Events 推送任务变更事件
+// 提供 Last-Event-ID 请求头或 last_event_id 参数时，先补发该事件之后的历史事件再推送实时事件
+func TaskEvents(c *gin.Context) {
+	var req dto.TaskEventsReq
+
+	// 绑定查询参数到 TaskEventsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.Error(c, apperrors.InvalidField("Last-Event-ID", "must be an event ID"))
+			return
+		}
+		req.LastEventID = id
+	}
+
+	// 先订阅再补发，避免补发期间产生的事件丢失
+	viewer := currentUser(c)
+	events, unsubscribe := services.SubscribeTaskEvents(viewer)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// 补发过的事件，之后作为实时事件再次收到时跳过
+	// 事件按发布顺序推送，不按 ID 去重：ID 较小的事件可能因事务提交较晚而较晚发布
+	// SSE 事件的 id 为续传位置而非事件 ID，不会越过尚未提交的事件，续传时可能重复收到之前的事件
+	replayed := map[uint64]bool{}
+	resume := req.LastEventID
+	if req.LastEventID > 0 {
+		var err error
+		resume, err = services.ReplayTaskEvents(viewer, req.LastEventID, func(event dto.TaskEvent) error {
+			replayed[event.ID] = true
+			return writeTaskEvent(c, event, event.Resume)
+		})
+		if err != nil {
+			log.Printf("Task event stream: %v", err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			// 处理过慢被断开，客户端重连时通过 Last-Event-ID 续传
+			if !ok {
+				return
+			}
+			if replayed[event.ID] {
+				delete(replayed, event.ID)
+				continue
+			}
+			resume = max(resume, event.Resume)
+			if err := writeTaskEvent(c, event, resume); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeTaskEvent 写入一个 SSE 事件，resume 为事件的 id，即断线续传的位置
+func writeTaskEvent(c *gin.Context, event dto.TaskEvent, resume uint64) error {
+	err := sse.Encode(c.Writer, sse.Event{
+		Id:    strconv.FormatUint(resume, 10),
+		Event: event.Type,
+		Data:  event,
+	})
+	if err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/graph"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GraphQL 执行 GraphQL 查询或变更
+func GraphQL(c *gin.Context) {
+	var req dto.GraphQLReq
+
+	// 绑定 JSON 数据到 GraphQLReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	result := graph.Execute(c.Request.Context(), req, graph.Limits{
+		MaxDepth:      config.GraphQLMaxDepth(),
+		MaxComplexity: config.GraphQLMaxComplexity(),
+	})
+
+	// GraphQL 的错误位于响应体的 errors 中，HTTP 状态码始终为 200
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHistory 获取任务的变更历史
+func TaskHistory(c *gin.Context) {
+	// 获取ID
+	id, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.TaskHistoryReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	history, err := services.GetTaskHistory(id, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, history, "Task history fetched successfully")
+}
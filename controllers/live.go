@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	liveWriteTimeout = 10 * time.Second // 单条消息的写超时
+	livePongTimeout  = 60 * time.Second // 超过该时间未收到 pong 视为连接已断开
+	livePingInterval = 50 * time.Second // ping 间隔，需小于 livePongTimeout
+	liveMaxMessage   = 4096             // 客户端消息的最大字节数
+
+	liveChannelTasks      = "tasks" // 全部任务
+	liveChannelTaskPrefix = "task:" // 单个任务，如 task:42
+)
+
+var liveUpgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// liveClient 一个 WebSocket 连接的订阅状态
+type liveClient struct {
+	user     string
+	mu       sync.Mutex
+	channels map[string]bool
+	out      chan dto.LiveServerMessage // 对请求的直接回复
+}
+
+// Live 建立 WebSocket 连接，推送已订阅任务的变更事件和在线状态
+// 调用方标识取自 X-User 请求头；浏览器无法为 WebSocket 设置请求头，仅在请求中没有 X-User 时才使用 user 参数，
+// 请求头存在时（即使为空）忽略 user 参数，避免绕过网关设置的调用方以其他用户的身份订阅
+// 推送处理过慢的连接会被关闭，客户端重连后重新订阅即可
+func Live(c *gin.Context) {
+	user := currentUser(c)
+	if len(c.Request.Header.Values(UserHeader)) == 0 {
+		user = c.Query("user")
+	}
+
+	conn, err := liveUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已向客户端返回错误
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribeEvents := services.SubscribeTaskEvents(user)
+	defer unsubscribeEvents()
+	updates, unsubscribePresence := services.SubscribePresence(user)
+	defer unsubscribePresence()
+
+	session := services.JoinPresence(user)
+	defer session.Leave()
+
+	client := &liveClient{user: user, channels: map[string]bool{}, out: make(chan dto.LiveServerMessage, 16)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.readLoop(conn, session)
+	}()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		var msg dto.LiveServerMessage
+		select {
+		case <-done:
+			return
+		case msg = <-client.out:
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			channel := client.subscribedChannel(event.Task.ID)
+			if channel == "" {
+				continue
+			}
+			msg = dto.LiveServerMessage{Type: "event", Channel: channel, Event: &event}
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			channel := client.subscribedChannel(update.TaskID)
+			if channel == "" {
+				continue
+			}
+			msg = dto.LiveServerMessage{Type: "presence", Channel: channel, Presence: &update}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+			continue
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// readLoop 读取客户端消息，连接断开时返回
+func (l *liveClient) readLoop(conn *websocket.Conn, session *services.PresenceSession) {
+	conn.SetReadLimit(liveMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		// 消息格式错误时回复错误并继续读取
+		var msg dto.LiveClientMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			l.reply(dto.LiveServerMessage{Type: "error", Error: "invalid message: " + err.Error()})
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&msg); err != nil {
+			l.reply(dto.LiveServerMessage{Type: "error", Error: "invalid message: " + err.Error()})
+			continue
+		}
+
+		switch msg.Type {
+		case "subscribe", "unsubscribe":
+			taskID, err := parseLiveChannel(msg.Channel)
+			if err != nil {
+				l.reply(dto.LiveServerMessage{Type: "error", Channel: msg.Channel, Error: err.Error()})
+				continue
+			}
+
+			// 订阅单个任务前确认任务存在且对调用方可见，并返回当前的在线用户
+			var update dto.PresenceUpdate
+			if msg.Type == "subscribe" && taskID != 0 {
+				if update, err = services.TaskPresence(l.user, taskID); err != nil {
+					l.reply(dto.LiveServerMessage{Type: "error", Channel: msg.Channel, Error: err.Error()})
+					continue
+				}
+			}
+
+			l.mu.Lock()
+			l.channels[msg.Channel] = msg.Type == "subscribe"
+			l.mu.Unlock()
+			l.reply(dto.LiveServerMessage{Type: msg.Type + "d", Channel: msg.Channel})
+			if msg.Type == "subscribe" && taskID != 0 {
+				l.reply(dto.LiveServerMessage{Type: "presence", Channel: msg.Channel, Presence: &update})
+			}
+		case "presence":
+			if msg.TaskID == 0 {
+				l.reply(dto.LiveServerMessage{Type: "error", Error: "task_id is required"})
+				continue
+			}
+			if err := session.Set(msg.TaskID, msg.State); err != nil {
+				l.reply(dto.LiveServerMessage{Type: "error", Error: err.Error()})
+			}
+		}
+	}
+}
+
+// reply 发送对请求的直接回复，发送队列已满时丢弃
+func (l *liveClient) reply(msg dto.LiveServerMessage) {
+	select {
+	case l.out <- msg:
+	default:
+	}
+}
+
+// subscribedChannel 返回任务所属的已订阅频道，优先返回单个任务的频道，未订阅时返回空
+func (l *liveClient) subscribedChannel(taskID uint) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if channel := liveChannelTaskPrefix + strconv.FormatUint(uint64(taskID), 10); l.channels[channel] {
+		return channel
+	}
+	if l.channels[liveChannelTasks] {
+		return liveChannelTasks
+	}
+	return ""
+}
+
+// parseLiveChannel 校验频道名，返回单个任务频道的任务 ID，tasks 频道返回 0
+func parseLiveChannel(channel string) (uint, error) {
+	if channel == liveChannelTasks {
+		return 0, nil
+	}
+	if idStr, ok := strings.CutPrefix(channel, liveChannelTaskPrefix); ok {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err == nil && id > 0 {
+			return uint(id), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown channel %q, expected %q or %q", channel, liveChannelTasks, liveChannelTaskPrefix+"<id>")
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+)
+
+// FetchTaskMembers 获取任务的负责人和关注者
+func FetchTaskMembers(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	members, err := services.FetchTaskMembers(taskID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, members, "Task members fetched successfully")
+}
+
+// AddAssignee 添加任务负责人
+func AddAssignee(c *gin.Context) {
+	addTaskMember(c, services.RoleAssignee, "Assignee added successfully")
+}
+
+// RemoveAssignee 移除任务负责人
+func RemoveAssignee(c *gin.Context) {
+	removeTaskMember(c, services.RoleAssignee, "Assignee removed successfully")
+}
+
+// AddWatcher 关注任务
+func AddWatcher(c *gin.Context) {
+	addTaskMember(c, services.RoleWatcher, "Watcher added successfully")
+}
+
+// RemoveWatcher 取消关注任务
+func RemoveWatcher(c *gin.Context) {
+	removeTaskMember(c, services.RoleWatcher, "Watcher removed successfully")
+}
+
+// addTaskMember 将请求体中的用户（默认为当前调用方）添加为任务的 role
+func addTaskMember(c *gin.Context, role, msg string) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 请求体可为空
+	var req dto.AddTaskMemberReq
+	if err = c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.BindError(c, err)
+		return
+	}
+
+	members, err := services.AddTaskMember(c.Request.Context(), taskID, role, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, members, msg)
+}
+
+// removeTaskMember 移除路径中的用户担任的任务 role
+func removeTaskMember(c *gin.Context, role, msg string) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if err = services.RemoveTaskMember(c.Request.Context(), taskID, role, c.Param("user")); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, msg)
+}
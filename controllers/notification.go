@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// FetchNotifications 获取当前调用方的通知
+func FetchNotifications(c *gin.Context) {
+	recipient, err := requireUser(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.FetchNotificationsReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	notifications, err := services.FetchNotifications(recipient, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, notifications, "Notifications fetched successfully")
+}
+
+// MarkNotificationRead 将当前调用方的一条通知标为已读
+func MarkNotificationRead(c *gin.Context) {
+	recipient, err := requireUser(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.Error(c, apperrors.InvalidField("id", "invalid notification ID"))
+		return
+	}
+
+	notification, err := services.MarkNotificationRead(recipient, id)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, notification, "Notification marked as read")
+}
+
+// MarkAllNotificationsRead 将当前调用方的全部通知标为已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	recipient, err := requireUser(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	resp, err := services.MarkAllNotificationsRead(recipient)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, resp, "Notifications marked as read")
+}
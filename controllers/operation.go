@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"strconv"
+)
+
+// FetchOperations 获取最近的任务操作
+func FetchOperations(c *gin.Context) {
+	var req dto.FetchOperationsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Actor == "me" {
+		req.Actor = currentUser(c)
+	}
+
+	operations, err := services.FetchOperations(req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, operations, "Operations fetched successfully")
+}
+
+// GetOperation 获取单个任务操作
+func GetOperation(c *gin.Context) {
+	id, err := getOperationIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	operation, err := services.GetOperation(id)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, operation, "Operation fetched successfully")
+}
+
+// UndoOperation 撤销任务操作
+func UndoOperation(c *gin.Context) {
+	id, err := getOperationIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 请求体可为空
+	var req dto.UndoOperationReq
+	if err = c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.BindError(c, err)
+		return
+	}
+
+	resp, err := services.UndoOperation(c.Request.Context(), id, req)
+	if err != nil {
+		// 整体回滚时返回各任务的处理结果
+		appErr := apperrors.From(err)
+		if len(resp.Results) > 0 {
+			appErr = appErr.WithData(resp)
+		}
+		utils.Error(c, appErr)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, resp, "Operation undone")
+}
+
+// getOperationIDFromParam 从 URL 参数中获取操作 ID
+func getOperationIDFromParam(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, apperrors.InvalidField("id", "invalid operation ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// FetchReminders 获取任务的提醒
+func FetchReminders(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	reminders, err := services.FetchReminders(taskID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, reminders, "Reminders fetched successfully")
+}
+
+// CreateReminder 为任务添加提醒
+func CreateReminder(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.CreateReminderReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	reminder, err := services.CreateReminder(c.Request.Context(), taskID, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Created(c, reminder, "Reminder created successfully")
+}
+
+// DeleteReminder 删除任务的提醒
+func DeleteReminder(c *gin.Context) {
+	taskID, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+	reminderID, err := strconv.Atoi(c.Param("reminder_id"))
+	if err != nil || reminderID <= 0 {
+		utils.Error(c, apperrors.InvalidField("reminder_id", "invalid reminder ID"))
+		return
+	}
+
+	if err = services.DeleteReminder(c.Request.Context(), taskID, uint(reminderID)); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, "Reminder deleted successfully")
+}
@@ -1,12 +1,13 @@
 package controllers
 
 import (
+	"E-Todo/apperrors"
 	"E-Todo/dto"
 	"E-Todo/services"
 	"E-Todo/utils"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"strconv"
+	"strings"
 )
 
 // CreateTask 创建任务
@@ -15,18 +16,19 @@ func CreateTask(c *gin.Context) {
 
 	// 绑定 JSON 数据到 CreateTaskReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
-	task, err := services.CreateTask(req)
+	task, err := services.CreateTask(c.Request.Context(), req)
 	if err != nil {
-		utils.Fail(c, nil, 1002, "Failed to create task")
+		utils.Error(c, err)
 		return
 	}
 
 	// 返回成功响应
-	utils.Success(c, task, "Task created successfully")
+	setETag(c, task.Version)
+	utils.Created(c, task, "Task created successfully")
 }
 
 // FetchAllTasks 获取所有任务
@@ -35,7 +37,7 @@ func FetchAllTasks(c *gin.Context) {
 
 	// 绑定查询参数到 FetchAllTasksReq
 	if err := c.ShouldBindQuery(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
@@ -47,9 +49,9 @@ func FetchAllTasks(c *gin.Context) {
 		req.Limit = 50
 	}
 
-	tasks, total, err := services.FetchAllTasks(req)
+	tasks, total, err := services.FetchAllTasks(c.Request.Context(), req)
 	if err != nil {
-		utils.Fail(c, nil, 1002, "Failed to fetch tasks")
+		utils.Error(c, err)
 		return
 	}
 
@@ -62,46 +64,131 @@ func FetchAllTasks(c *gin.Context) {
 	}, "Tasks fetched successfully")
 }
 
+// GetTask 获取单个任务
+func GetTask(c *gin.Context) {
+	// 获取ID
+	id, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 解析 expand 参数，支持 ?expand=a,b 和 ?expand=a&expand=b
+	var expand []string
+	for _, value := range c.QueryArray("expand") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				expand = append(expand, name)
+			}
+		}
+	}
+
+	task, err := services.GetTask(id, expand)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	setETag(c, task.Version)
+	utils.Success(c, task, "Task fetched successfully")
+}
+
 // UpdateTask 更新任务
 func UpdateTask(c *gin.Context) {
 	var req dto.UpdateTaskReq
 
 	// 获取ID
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil || id <= 0 {
-		utils.Fail(c, nil, 1001, "Invalid task ID")
+	id, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
 	// 绑定 JSON 数据到 UpdateTaskReq
-	req.ID = uint(id)
+	req.ID = id
 	if err = c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
+		return
+	}
+
+	// If-Match 请求头优先于请求体中的版本
+	version, err := getIfMatchVersion(c)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
+	if version != 0 {
+		req.Version = version
+	}
 
-	task, err := services.UpdateTask(req)
+	task, err := services.UpdateTask(c.Request.Context(), req)
 	if err != nil {
-		utils.Fail(c, nil, 1002, fmt.Sprintf("Failed to update task:%v", err))
+		failTask(c, err)
 		return
 	}
 
 	// 返回成功响应
+	setETag(c, task.Version)
 	utils.Success(c, task, "Task updated successfully")
 }
 
+// PatchTask 部分更新任务
+// 支持 application/merge-patch+json（默认，RFC 7396）和 application/json-patch+json（RFC 6902）
+func PatchTask(c *gin.Context) {
+	// 获取ID
+	id, err := getIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	version, err := getIfMatchVersion(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	var task dto.TaskDTO
+	if c.ContentType() == "application/json-patch+json" {
+		task, err = services.JSONPatchTask(c.Request.Context(), id, version, body)
+	} else {
+		task, err = services.MergePatchTask(c.Request.Context(), id, version, body)
+	}
+	if err != nil {
+		failTask(c, err)
+		return
+	}
+
+	// 返回成功响应
+	setETag(c, task.Version)
+	utils.Success(c, task, "Task patched successfully")
+}
+
 // DeleteTask 硬删除删除任务
 func DeleteTask(c *gin.Context) {
 	// 获取ID
 	id, err := getIDFromParam(c)
 	if err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.Error(c, err)
+		return
+	}
+
+	version, err := getIfMatchVersion(c)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
-	err = services.DeleteTask(id)
+	err = services.DeleteTask(c.Request.Context(), id, version)
 	if err != nil {
-		utils.Fail(c, nil, 1002, "Failed to delete task")
+		failTask(c, err)
 		return
 	}
 
@@ -114,13 +201,19 @@ func SoftDelete(c *gin.Context) {
 	// 获取ID
 	id, err := getIDFromParam(c)
 	if err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
-	err = services.SoftDelete(id)
+	version, err := getIfMatchVersion(c)
 	if err != nil {
-		utils.Fail(c, nil, 1002, "Failed to soft delete task")
+		utils.Error(c, err)
+		return
+	}
+
+	err = services.SoftDelete(c.Request.Context(), id, version)
+	if err != nil {
+		failTask(c, err)
 		return
 	}
 
@@ -133,13 +226,19 @@ func RestoreTask(c *gin.Context) {
 	// 获取ID
 	id, err := getIDFromParam(c)
 	if err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.Error(c, err)
+		return
+	}
+
+	version, err := getIfMatchVersion(c)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
-	err = services.RestoreTask(id)
+	err = services.RestoreTask(c.Request.Context(), id, version)
 	if err != nil {
-		utils.Fail(c, nil, 1002, fmt.Sprintf("Failed to restore task: %v", err))
+		failTask(c, err)
 		return
 	}
 
@@ -151,7 +250,7 @@ func RestoreTask(c *gin.Context) {
 func getIDFromParam(c *gin.Context) (uint, error) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		return 0, fmt.Errorf("invalid task ID")
+		return 0, apperrors.InvalidField("id", "invalid task ID")
 	}
 	return uint(id), nil
 }
@@ -161,13 +260,19 @@ func CompleteTask(c *gin.Context) {
 	// 获取ID
 	id, err := getIDFromParam(c)
 	if err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
-	err = services.CompleteTask(id)
+	version, err := getIfMatchVersion(c)
 	if err != nil {
-		utils.Fail(c, nil, 1002, fmt.Sprintf("Failed to complete task: %v", err))
+		utils.Error(c, err)
+		return
+	}
+
+	err = services.CompleteTask(c.Request.Context(), id, version)
+	if err != nil {
+		failTask(c, err)
 		return
 	}
 
@@ -181,23 +286,24 @@ func BatchDeleteTasks(c *gin.Context) {
 
 	// 绑定 JSON 数据到 BatchDeleteTasksReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 校验 IDs 是否为空
 	if len(req.IDs) == 0 {
-		utils.Fail(c, nil, 1001, "No task IDs provided")
+		utils.Error(c, apperrors.InvalidField("ids", "no task IDs provided"))
 		return
 	}
 
-	if err := services.BatchDeleteTasks(req); err != nil {
-		utils.Fail(c, nil, 1002, "Failed to batch delete tasks")
+	resp, err := services.BatchDeleteTasks(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, apperrors.From(err).WithData(resp))
 		return
 	}
 
 	// 返回成功响应
-	utils.Success(c, nil, "Tasks batch deleted successfully")
+	utils.Success(c, resp, "Tasks batch deleted successfully")
 }
 
 // BatchCompleteTasks 批量完成任务
@@ -206,23 +312,24 @@ func BatchCompleteTasks(c *gin.Context) {
 
 	// 绑定 JSON 数据到 BatchCompleteTasksReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 校验 IDs 是否为空
 	if len(req.IDs) == 0 {
-		utils.Fail(c, nil, 1001, "No task IDs provided")
+		utils.Error(c, apperrors.InvalidField("ids", "no task IDs provided"))
 		return
 	}
 
-	if err := services.BatchCompleteTasks(req); err != nil {
-		utils.Fail(c, nil, 1002, "Failed to batch complete tasks")
+	resp, err := services.BatchCompleteTasks(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, apperrors.From(err).WithData(resp))
 		return
 	}
 
 	// 返回成功响应
-	utils.Success(c, nil, "Tasks batch completed successfully")
+	utils.Success(c, resp, "Tasks batch completed successfully")
 }
 
 // BatchSoftDeleteTasks 批量软删除任务
@@ -231,23 +338,24 @@ func BatchSoftDeleteTasks(c *gin.Context) {
 
 	// 绑定 JSON 数据
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 校验 IDs 是否为空
 	if len(req.IDs) == 0 {
-		utils.Fail(c, nil, 1001, "No task IDs provided")
+		utils.Error(c, apperrors.InvalidField("ids", "no task IDs provided"))
 		return
 	}
 
-	if err := services.BatchSoftDeleteTasks(req); err != nil {
-		utils.Fail(c, nil, 1002, "Failed to batch soft delete tasks")
+	resp, err := services.BatchSoftDeleteTasks(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, apperrors.From(err).WithData(resp))
 		return
 	}
 
 	// 返回成功响应
-	utils.Success(c, nil, "Tasks batch soft deleted successfully")
+	utils.Success(c, resp, "Tasks batch soft deleted successfully")
 }
 
 // BatchRestoreTasks 批量恢复任务
@@ -256,21 +364,22 @@ func BatchRestoreTasks(c *gin.Context) {
 
 	// 绑定 JSON 数据
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Fail(c, nil, 1001, err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 校验 IDs 是否为空
 	if len(req.IDs) == 0 {
-		utils.Fail(c, nil, 1001, "No task IDs provided")
+		utils.Error(c, apperrors.InvalidField("ids", "no task IDs provided"))
 		return
 	}
 
-	if err := services.BatchRestoreTasks(req); err != nil {
-		utils.Fail(c, nil, 1002, "Failed to batch restore tasks")
+	resp, err := services.BatchRestoreTasks(c.Request.Context(), req)
+	if err != nil {
+		utils.Error(c, apperrors.From(err).WithData(resp))
 		return
 	}
 
 	// 返回成功响应
-	utils.Success(c, nil, "Tasks batch restored successfully")
+	utils.Success(c, resp, "Tasks batch restored successfully")
 }
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// FetchTrash 获取回收站中的任务
+func FetchTrash(c *gin.Context) {
+	var req dto.FetchTrashReq
+
+	// 绑定查询参数到 FetchTrashReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	tasks, total, err := services.FetchTrashTasks(req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, dto.FetchAllTasksResp{
+		Tasks: tasks,
+		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+	}, "Trash fetched successfully")
+}
+
+// EmptyTrash 清空回收站
+func EmptyTrash(c *gin.Context) {
+	purged, err := services.EmptyTrash(c.Request.Context())
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, dto.EmptyTrashResp{Purged: purged}, "Trash emptied successfully")
+}
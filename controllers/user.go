@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHeader 调用方标识请求头
+const UserHeader = "X-User"
+
+// currentUser 返回调用方标识，未提供时为空
+func currentUser(c *gin.Context) string {
+	return c.GetHeader(UserHeader)
+}
+
+// requireUser 返回调用方标识，未提供时返回校验错误
+func requireUser(c *gin.Context) (string, error) {
+	user := currentUser(c)
+	if user == "" {
+		return "", apperrors.InvalidField(UserHeader, "header is required")
+	}
+	return user, nil
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// CreateWebhook 创建 Webhook 订阅
+func CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookReq
+
+	// 绑定 JSON 数据到 CreateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	webhook, err := services.CreateWebhook(req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Created(c, webhook, "Webhook created successfully")
+}
+
+// FetchWebhooks 获取全部 Webhook 订阅
+func FetchWebhooks(c *gin.Context) {
+	webhooks, err := services.FetchWebhooks()
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, webhooks, "Webhooks fetched successfully")
+}
+
+// GetWebhook 获取单个 Webhook 订阅
+func GetWebhook(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	webhook, err := services.GetWebhook(id)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, webhook, "Webhook fetched successfully")
+}
+
+// UpdateWebhook 更新 Webhook 订阅
+func UpdateWebhook(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.UpdateWebhookReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	webhook, err := services.UpdateWebhook(id, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, webhook, "Webhook updated successfully")
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+func DeleteWebhook(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if err = services.DeleteWebhook(id); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, nil, "Webhook deleted successfully")
+}
+
+// FetchWebhookDeliveries 获取 Webhook 的投递记录
+func FetchWebhookDeliveries(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var req dto.WebhookDeliveriesReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	deliveries, err := services.FetchWebhookDeliveries(id, req)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, deliveries, "Webhook deliveries fetched successfully")
+}
+
+// RedeliverWebhook 重新投递
+func RedeliverWebhook(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil || deliveryID == 0 {
+		utils.Error(c, apperrors.InvalidField("delivery_id", "invalid delivery ID"))
+		return
+	}
+
+	delivery, err := services.RedeliverWebhook(id, deliveryID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 返回成功响应
+	utils.Success(c, delivery, "Webhook delivery queued for redelivery")
+}
+
+// getWebhookIDFromParam 从 URL 参数中获取 Webhook ID
+func getWebhookIDFromParam(c *gin.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, apperrors.InvalidField("id", "invalid webhook ID")
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,23 @@
+package dto
+
+// UploadAttachmentForm 上传附件的表单，仅用于 API 文档，multipart/form-data 格式
+type UploadAttachmentForm struct {
+	File string `json:"file" binding:"required"` // 文件内容
+}
+
+// AttachmentDTO 附件元数据
+type AttachmentDTO struct {
+	ID        uint   `json:"id"`
+	TaskID    uint   `json:"task_id"`
+	Name      string `json:"name"`
+	Size      int64  `json:"size"` // 字节数
+	MimeType  string `json:"mime_type"`
+	Checksum  string `json:"checksum"` // 内容的 SHA-256，十六进制
+	Uploader  string `json:"uploader"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FetchAttachmentsResp 任务的附件列表
+type FetchAttachmentsResp struct {
+	Attachments []AttachmentDTO `json:"attachments"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+// CreateChecklistItemReq 添加检查项请求参数
+type CreateChecklistItemReq struct {
+	Text     string `json:"text" binding:"required,max=500"`    // 检查项内容，必填
+	Checked  bool   `json:"checked"`                            // 是否已勾选，默认未勾选
+	Position *int   `json:"position" binding:"omitempty,min=0"` // 插入位置（从 0 开始），选填，默认追加到末尾
+}
+
+// UpdateChecklistItemReq 修改检查项请求参数，至少提供一个字段
+type UpdateChecklistItemReq struct {
+	Text    *string `json:"text" binding:"omitempty,min=1,max=500"` // 检查项内容
+	Checked *bool   `json:"checked"`                                // 勾选或取消勾选
+}
+
+// ReorderChecklistReq 重新排列检查项请求参数
+type ReorderChecklistReq struct {
+	ItemIDs []uint `json:"item_ids" binding:"required"` // 按新顺序排列的全部检查项 ID
+}
+
+// ChecklistItemDTO 检查项
+type ChecklistItemDTO struct {
+	ID        uint   `json:"id"`
+	TaskID    uint   `json:"task_id"`
+	Text      string `json:"text"`
+	Checked   bool   `json:"checked"`
+	Position  int    `json:"position"` // 从 0 开始的序号
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ChecklistDTO 任务的检查清单
+type ChecklistDTO struct {
+	Items    []ChecklistItemDTO `json:"items"` // 按 position 排列
+	Total    int                `json:"total"`
+	Checked  int                `json:"checked"`
+	Progress *int               `json:"progress"` // 完成百分比（0-100），没有检查项时为 null
+}
@@ -0,0 +1,38 @@
+package dto
+
+// CreateCommentReq 创建评论请求参数
+type CreateCommentReq struct {
+	Body     string `json:"body" binding:"required,max=10000"` // 评论内容，Markdown 格式，必填
+	ParentID *uint  `json:"parent_id"`                         // 回复的评论 ID，选填
+}
+
+// UpdateCommentReq 修改评论请求参数
+type UpdateCommentReq struct {
+	Body string `json:"body" binding:"required,max=10000"` // 评论内容，Markdown 格式，必填
+}
+
+// FetchCommentsReq 获取评论请求参数
+type FetchCommentsReq struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"` // 每页的顶层评论数量
+}
+
+// CommentDTO 评论及其回复
+type CommentDTO struct {
+	ID        uint         `json:"id"`
+	TaskID    uint         `json:"task_id"`
+	ParentID  *uint        `json:"parent_id,omitempty"`
+	Author    string       `json:"author"`
+	Body      string       `json:"body"` // Markdown 格式
+	CreatedAt string       `json:"created_at"`
+	UpdatedAt string       `json:"updated_at"`
+	Replies   []CommentDTO `json:"replies"` // 按时间顺序排列的回复
+}
+
+// FetchCommentsResp 评论分页结果，按顶层评论分页，每条评论包含全部回复
+type FetchCommentsResp struct {
+	Comments []CommentDTO `json:"comments"`
+	Total    int64        `json:"total"` // 顶层评论数量
+	Page     int          `json:"page"`
+	Limit    int          `json:"limit"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// GraphQLReq GraphQL 请求参数
+type GraphQLReq struct {
+	Query         string                 `json:"query" binding:"required"` // 查询语句，必填
+	OperationName string                 `json:"operationName"`            // 文档中包含多个操作时要执行的操作名，选填
+	Variables     map[string]interface{} `json:"variables"`                // 变量，选填
+}
@@ -0,0 +1,31 @@
+package dto
+
+// LiveClientMessage 客户端通过 WebSocket 发送的消息
+type LiveClientMessage struct {
+	Type    string `json:"type" binding:"required,oneof=subscribe unsubscribe presence"`
+	Channel string `json:"channel"`                                         // subscribe / unsubscribe：tasks 表示全部任务，task:<id> 表示单个任务
+	TaskID  uint   `json:"task_id"`                                         // presence：所在的任务
+	State   string `json:"state" binding:"omitempty,oneof=viewing editing"` // presence：viewing / editing，为空表示离开该任务
+}
+
+// LiveServerMessage 服务端通过 WebSocket 推送的消息
+type LiveServerMessage struct {
+	Type     string          `json:"type"` // subscribed / unsubscribed / event / presence / error
+	Channel  string          `json:"channel,omitempty"`
+	Event    *TaskEvent      `json:"event,omitempty"`
+	Presence *PresenceUpdate `json:"presence,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// PresenceUpdate 某个任务当前的在线用户
+type PresenceUpdate struct {
+	TaskID uint           `json:"task_id"`
+	Users  []PresenceUser `json:"users"`
+}
+
+// PresenceUser 在线用户及其状态
+type PresenceUser struct {
+	User  string `json:"user"`
+	State string `json:"state"` // viewing / editing
+	Since string `json:"since"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// AddTaskMemberReq 添加负责人或关注者请求参数
+type AddTaskMemberReq struct {
+	User string `json:"user" binding:"omitempty,max=255"` // 用户标识，me 或为空表示当前调用方（X-User）
+}
+
+// TaskMembersDTO 任务的负责人和关注者
+type TaskMembersDTO struct {
+	Assignees []string `json:"assignees"`
+	Watchers  []string `json:"watchers"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+// FetchNotificationsReq 获取通知请求参数
+type FetchNotificationsReq struct {
+	Page   int  `form:"page" binding:"omitempty,min=1"`
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Unread bool `form:"unread"` // 只返回未读通知
+}
+
+// NotificationDTO 通知
+type NotificationDTO struct {
+	ID        uint64 `json:"id"`
+	Type      string `json:"type"` // mentioned / assigned
+	TaskID    uint   `json:"task_id"`
+	CommentID *uint  `json:"comment_id,omitempty"` // 在评论中提及时为评论 ID
+	Actor     string `json:"actor"`                // 触发通知的用户
+	Excerpt   string `json:"excerpt,omitempty"`    // 提及所在文本的摘要
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FetchNotificationsResp 通知分页结果
+type FetchNotificationsResp struct {
+	Notifications []NotificationDTO `json:"notifications"`
+	Total         int64             `json:"total"`
+	Unread        int64             `json:"unread"` // 全部未读通知数
+	Page          int               `json:"page"`
+	Limit         int               `json:"limit"`
+}
+
+// MarkAllNotificationsReadResp 全部标为已读的结果
+type MarkAllNotificationsReadResp struct {
+	Marked int64 `json:"marked"` // 标为已读的通知数
+}
@@ -0,0 +1,51 @@
+package dto
+
+// FetchOperationsReq 获取任务操作请求参数
+type FetchOperationsReq struct {
+	Page      int    `form:"page" binding:"omitempty,min=1"`
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Actor     string `form:"actor"`      // 按调用方过滤，选填；me 表示当前调用方（X-User）
+	RequestID string `form:"request_id"` // 按请求 ID 过滤，选填
+}
+
+// OperationDTO 一次任务变更操作（单个或批量）
+type OperationDTO struct {
+	ID        uint64 `json:"id"`
+	Action    string `json:"action"` // 操作类型，与事件类型一致，如 task.soft_deleted；撤销操作为 task.undo，混合批量操作为 task.batch
+	TaskIDs   []uint `json:"task_ids"`
+	Actor     string `json:"actor"`
+	RequestID string `json:"request_id"`
+	UndoOf    uint64 `json:"undo_of,omitempty"`   // 撤销操作所撤销的操作 ID
+	UndoneAt  string `json:"undone_at,omitempty"` // 被撤销的时间
+	Undoable  bool   `json:"undoable"`            // 是否仍可撤销
+	ExpiresAt string `json:"expires_at"`          // 可撤销的截止时间
+	CreatedAt string `json:"created_at"`
+}
+
+// FetchOperationsResp 任务操作分页结果
+type FetchOperationsResp struct {
+	Operations []OperationDTO `json:"operations"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+}
+
+// UndoOperationReq 撤销操作请求参数，请求体可为空
+type UndoOperationReq struct {
+	Atomic bool `json:"atomic"` // 事务模式，选填：任一任务无法撤销时整体回滚
+}
+
+// UndoItemResult 撤销操作中单个任务的处理结果
+type UndoItemResult struct {
+	TaskID uint     `json:"task_id"`
+	Result string   `json:"result"`         // ok / not_found / modified（操作之后已被修改）
+	Task   *TaskDTO `json:"task,omitempty"` // 撤销后的任务；无法撤销时为当前的任务，便于手动处理
+}
+
+// UndoOperationResp 撤销操作响应参数
+type UndoOperationResp struct {
+	Operation *OperationDTO    `json:"operation,omitempty"` // 撤销产生的新操作，可再次撤销以重做；没有任务被撤销时为空
+	Results   []UndoItemResult `json:"results"`
+	Succeeded int              `json:"succeeded"` // 撤销成功的任务数量
+	Failed    int              `json:"failed"`    // 无法撤销的任务数量
+}
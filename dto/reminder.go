@@ -0,0 +1,31 @@
+package dto
+
+// CreateReminderReq 添加提醒请求参数，remind_at 与 offset_minutes 二选一
+type CreateReminderReq struct {
+	RemindAt      string `json:"remind_at"`                                                 // 提醒时间 (格式：yyyy-MM-ddTHH:mmZ)
+	OffsetMinutes *int   `json:"offset_minutes" binding:"omitempty,min=-525600,max=525600"` // 相对截止时间的分钟数（不超过一年），负数表示截止前，截止时间修改后随之调整
+	Note          string `json:"note" binding:"omitempty,max=500"`                          // 提醒内容，选填
+}
+
+// ReminderDTO 任务提醒
+type ReminderDTO struct {
+	ID            uint    `json:"id"`
+	TaskID        uint    `json:"task_id"`
+	RemindAt      *string `json:"remind_at"`      // 绝对提醒时间，相对截止时间的提醒为 null
+	OffsetMinutes *int    `json:"offset_minutes"` // 相对截止时间的分钟数，绝对时间的提醒为 null
+	FireAt        string  `json:"fire_at"`        // 实际触发时间
+	Note          string  `json:"note"`
+	Status        string  `json:"status"`   // pending, sent, skipped, failed
+	FiredAt       *string `json:"fired_at"` // 发送或跳过的时间
+	CreatedBy     string  `json:"created_by"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// ReminderNotice 发送给提醒渠道的提醒内容
+type ReminderNotice struct {
+	ReminderID uint     `json:"reminder_id"`
+	Task       TaskDTO  `json:"task"`
+	FireAt     string   `json:"fire_at"`
+	Note       string   `json:"note"`
+	Recipients []string `json:"recipients"` // 任务的负责人、关注者和提醒的创建者，可能为空
+}
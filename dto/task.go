@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 // CreateTaskReq 定义请求数据结构
 type CreateTaskReq struct {
 	Title       string `json:"title" binding:"required"`    // 任务标题，必填
@@ -18,6 +20,12 @@ type FetchAllTasksReq struct {
 	Status        string `form:"status"`                         // 状态搜索
 	Color         string `form:"color"`                          // 颜色搜索
 	RemainingDays int    `form:"remaining_days" binding:"gte=0"` // 剩余天数搜索
+	AsOf          string `form:"as_of"`                          // 查询任务在该时刻的状态，选填 (格式：RFC 3339 或 yyyy-MM-ddTHH:mmZ)
+	Assignee      string `form:"assignee"`                       // 按负责人过滤，选填；me 表示当前调用方（X-User）
+	Watcher       string `form:"watcher"`                        // 按关注者过滤，选填；me 表示当前调用方（X-User）
+
+	// 排序字段，前缀 - 表示倒序
+	Sort string `form:"sort" binding:"omitempty,oneof=id -id title -title due_date -due_date status -status created_at -created_at updated_at -updated_at"`
 }
 
 // FetchAllTasksResp 获取所有任务响应参数
@@ -30,15 +38,25 @@ type FetchAllTasksResp struct {
 
 // TaskDTO 任务数据传输对象
 type TaskDTO struct {
-	ID          uint   `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Category    string `json:"category"`
-	Color       string `json:"color"`
-	DueDate     string `json:"due_date"`
-	Status      string `json:"status"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Category     string `json:"category"`
+	Color        string `json:"color"`
+	DueDate      string `json:"due_date"`
+	Status       string `json:"status"`
+	Version      uint   `json:"version"`
+	CommentCount int    `json:"comment_count"`
+	Progress     *int   `json:"progress"` // 检查项完成百分比（0-100），没有检查项时为 null
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
+}
+
+// TaskDetailDTO 单个任务详情，Expand 中包含通过 ?expand= 请求的关联数据
+type TaskDetailDTO struct {
+	TaskDTO
+	Expand map[string]interface{} `json:"expand,omitempty"`
 }
 
 // UpdateTaskReq 更新任务请求参数
@@ -50,9 +68,161 @@ type UpdateTaskReq struct {
 	Color       string `json:"color"`                 // 颜色标记，选填
 	DueDate     string `json:"due_date"`              // 截止日期，选填 (格式：yyyy-MM-ddTHH:mmZ)
 	Status      string `json:"status"`                // 任务状态，选填
+	Version     uint   `json:"version"`               // 期望的任务版本，选填；也可通过 If-Match 请求头传递
 }
 
 // BatchTaskActionReq 批量任务操作请求参数
 type BatchTaskActionReq struct {
-	IDs []uint `json:"ids" binding:"required"`
+	IDs    []uint `json:"ids" binding:"required"`
+	Atomic bool   `json:"atomic"` // 事务模式，选填：任一任务无法处理时整批回滚
+}
+
+// BatchItemResult 批量操作中单个任务的处理结果
+type BatchItemResult struct {
+	ID     uint   `json:"id"`
+	Result string `json:"result"` // ok / not_found / already_completed / already_deleted / not_deleted / forbidden / checklist_incomplete
+}
+
+// BatchTaskActionResp 批量任务操作响应参数
+type BatchTaskActionResp struct {
+	Results   []BatchItemResult `json:"results"`
+	Succeeded int               `json:"succeeded"` // 处理成功的任务数量
+	Failed    int               `json:"failed"`    // 无法处理的任务数量
+	Affected  int64             `json:"affected"`  // 数据库实际受影响的行数
+}
+
+// FetchTrashReq 获取回收站任务请求参数
+type FetchTrashReq struct {
+	Page  int `form:"page"`  // 页码
+	Limit int `form:"limit"` // 每页数量
+}
+
+// EmptyTrashResp 清空回收站响应参数
+type EmptyTrashResp struct {
+	Purged int64 `json:"purged"` // 被彻底删除的任务数量
+}
+
+// BatchOperation 批量混合操作中的单个操作
+type BatchOperation struct {
+	// 操作类型，必填：create / update / complete / soft_delete / restore / delete / move_category
+	Op       string          `json:"op" binding:"required,oneof=create update complete soft_delete restore delete move_category"`
+	ID       uint            `json:"id"`       // 目标任务 ID，与 ref 二选一
+	Ref      string          `json:"ref"`      // create 操作中为新任务命名；其他操作中引用同批次内已创建的任务
+	Version  uint            `json:"version"`  // 期望的任务版本，选填，不一致时整批回滚
+	Category string          `json:"category"` // move_category 操作的目标分类
+	Data     json.RawMessage `json:"data"`     // create 操作为 CreateTaskReq，update 操作为 UpdateTaskReq（无需 id）
+}
+
+// BatchOperationsReq 批量混合操作请求参数
+type BatchOperationsReq struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BatchOperationResult 批量混合操作中单个操作的执行结果
+type BatchOperationResult struct {
+	Index  int      `json:"index"`
+	Op     string   `json:"op"`
+	ID     uint     `json:"id,omitempty"`
+	Ref    string   `json:"ref,omitempty"`
+	Status string   `json:"status"` // ok / error / skipped
+	Error  string   `json:"error,omitempty"`
+	Task   *TaskDTO `json:"task,omitempty"`
+}
+
+// BatchOperationsResp 批量混合操作响应参数
+type BatchOperationsResp struct {
+	Results   []BatchOperationResult `json:"results"`
+	Committed bool                   `json:"committed"` // 事务是否已提交
+}
+
+// BulkUpdateTasksReq 按条件批量更新任务请求参数
+type BulkUpdateTasksReq struct {
+	Filter       BulkUpdateFilter  `json:"filter"`                                  // 筛选条件，与 FetchAllTasksReq 一致
+	Changes      BulkUpdateChanges `json:"changes"`                                 // 字段变更，至少包含一项
+	DryRun       bool              `json:"dry_run"`                                 // 试运行，只返回受影响数量和预览，不写入
+	PreviewLimit int               `json:"preview_limit" binding:"omitempty,gte=0"` // 预览条数，默认 20
+}
+
+// BulkUpdateFilter 批量更新筛选条件
+type BulkUpdateFilter struct {
+	KeyWords      string `json:"keywords"`                                 // 关键字搜索
+	Category      string `json:"category"`                                 // 分类搜索
+	Status        string `json:"status"`                                   // 状态搜索
+	Color         string `json:"color"`                                    // 颜色搜索
+	RemainingDays *int   `json:"remaining_days" binding:"omitempty,gte=0"` // 剩余天数搜索，不填表示不限
+}
+
+// BulkUpdateChanges 批量更新的字段变更，未填写的字段保持不变
+type BulkUpdateChanges struct {
+	Category          *string `json:"category"`                                           // 分类，空字符串表示移出分类
+	Color             *string `json:"color"`                                              // 颜色标记
+	Status            *string `json:"status" binding:"omitempty,oneof=pending completed"` // 任务状态
+	DueDate           *string `json:"due_date"`                                           // 截止日期 (格式：yyyy-MM-ddTHH:mmZ)，与顺延互斥
+	ShiftDueDateDays  int     `json:"shift_due_date_days"`                                // 截止日期顺延天数，负数表示提前
+	ShiftDueDateHours int     `json:"shift_due_date_hours"`                               // 截止日期顺延小时数，负数表示提前
+}
+
+// BulkUpdatePreview 批量更新预览，展示单个任务变更前后的内容
+type BulkUpdatePreview struct {
+	Before TaskDTO `json:"before"`
+	After  TaskDTO `json:"after"`
+}
+
+// BulkUpdateTasksResp 按条件批量更新任务响应参数
+type BulkUpdateTasksResp struct {
+	DryRun   bool                `json:"dry_run"`
+	Matched  int                 `json:"matched"`  // 符合条件的任务数量
+	Affected int                 `json:"affected"` // 实际更新的任务数量，不含变更后与原值相同的任务，试运行时为 0
+	Preview  []BulkUpdatePreview `json:"preview"`  // 会被修改的任务的变更预览，最多 preview_limit 条
+}
+
+// JSONPatchOperation JSON Patch (RFC 6902) 单个操作
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`    // add / remove / replace / test / copy / move
+	Path  string          `json:"path"`  // 目标字段，如 /title
+	From  string          `json:"from"`  // copy / move 操作的源字段
+	Value json.RawMessage `json:"value"` // add / replace / test 操作的值
+}
+
+// TaskEvent 任务变更事件
+type TaskEvent struct {
+	ID        uint64   `json:"id"`
+	Type      string   `json:"type"` // task.created / task.updated / task.completed / task.soft_deleted / task.restored / task.deleted
+	Task      TaskDTO  `json:"task"` // 变更后的任务，硬删除为删除前的任务
+	CreatedAt string   `json:"created_at"`
+	Audience  []string `json:"-"` // 可见该事件的用户，为空表示所有人可见
+	Resume    uint64   `json:"-"` // 断线续传的位置，作为 SSE 事件的 id：不大于该 ID 的事件均已推送或已视为回滚
+}
+
+// TaskEventsReq 订阅任务变更事件请求参数
+type TaskEventsReq struct {
+	LastEventID uint64 `form:"last_event_id"` // 从该位置之后续传（最后收到的 SSE 事件 id），选填；也可通过 Last-Event-ID 请求头传递
+}
+
+// TaskHistoryReq 查询任务变更历史请求参数
+type TaskHistoryReq struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TaskHistoryDTO 任务的一次字段变更
+type TaskHistoryDTO struct {
+	ID          uint64 `json:"id"`
+	TaskID      uint   `json:"task_id"`
+	OperationID uint64 `json:"operation_id"` // 所属的操作，可通过 POST /operations/:id/undo 撤销
+	Action      string `json:"action"`       // 变更类型，与事件类型一致，如 task.updated
+	Field       string `json:"field"`
+	OldValue    string `json:"old_value"`
+	NewValue    string `json:"new_value"`
+	Actor       string `json:"actor"`
+	RequestID   string `json:"request_id"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// TaskHistoryResp 任务变更历史分页结果
+type TaskHistoryResp struct {
+	History []TaskHistoryDTO `json:"history"`
+	Total   int64            `json:"total"`
+	Page    int              `json:"page"`
+	Limit   int              `json:"limit"`
 }
@@ -0,0 +1,57 @@
+package dto
+
+// CreateWebhookReq 创建 Webhook 订阅请求参数
+type CreateWebhookReq struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=255"` // 签名密钥，选填，为空时自动生成
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=task.created task.updated task.completed task.soft_deleted task.restored task.deleted"`
+	Active     *bool    `json:"active"` // 是否启用，选填，默认启用
+}
+
+// UpdateWebhookReq 更新 Webhook 订阅请求参数，未提供的字段保持不变
+type UpdateWebhookReq struct {
+	URL        string   `json:"url" binding:"omitempty,url,max=2048"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1,dive,oneof=task.created task.updated task.completed task.soft_deleted task.restored task.deleted"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookDTO Webhook 订阅
+type WebhookDTO struct {
+	ID         uint     `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"` // 仅在创建时返回
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+// WebhookDeliveriesReq 查询投递记录请求参数
+type WebhookDeliveriesReq struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// WebhookDeliveryDTO 一次事件投递
+type WebhookDeliveryDTO struct {
+	ID             uint64 `json:"id"`
+	WebhookID      uint   `json:"webhook_id"`
+	EventID        uint64 `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"` // pending / succeeded / failed
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"` // 仅 pending 状态返回
+	ResponseStatus int    `json:"response_status,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// WebhookDeliveriesResp 投递记录分页结果
+type WebhookDeliveriesResp struct {
+	Deliveries []WebhookDeliveryDTO `json:"deliveries"`
+	Total      int64                `json:"total"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
+}
@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"E-Todo/apperrors"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+	"strings"
+)
+
+// Limits 查询复杂度限制，0 表示不限制
+type Limits struct {
+	MaxDepth      int // 最大嵌套深度
+	MaxComplexity int // 最大复杂度：每个字段计 1，带 limit 参数的字段的子字段按 limit 倍计算
+}
+
+// complexity 计算查询的复杂度与嵌套深度
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	maxDepth  int
+}
+
+// checkComplexity 检查文档中每个操作的嵌套深度和复杂度，超出限制时返回错误
+func checkComplexity(doc *ast.Document, variables map[string]interface{}, limits Limits) *apperrors.Error {
+	c := complexity{fragments: map[string]*ast.FragmentDefinition{}, variables: variables, maxDepth: limits.MaxDepth}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		root := Schema.QueryType()
+		if operation.Operation == ast.OperationTypeMutation {
+			root = Schema.MutationType()
+		}
+		cost, depth := c.selectionSet(operation.SelectionSet, root, 1, map[string]bool{})
+
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return apperrors.Newf(apperrors.CodeQueryTooComplex, "query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+		}
+		if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+			return apperrors.Newf(apperrors.CodeQueryTooComplex, "query complexity %d exceeds the limit of %d", cost, limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+// selectionSet 返回选择集的复杂度和其中字段的最大深度，parent 为选择集所属的类型
+func (c complexity) selectionSet(set *ast.SelectionSet, parent graphql.Type, depth int, visiting map[string]bool) (int, int) {
+	if set == nil {
+		return 0, depth - 1
+	}
+	// 深度已超出限制时不再继续展开，避免构造的深层查询耗费过多计算
+	if c.maxDepth > 0 && depth > c.maxDepth {
+		return 0, depth
+	}
+
+	cost, maxDepth := 0, depth-1
+	add := func(n, d int) {
+		cost += n
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			// 内省字段不计入
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			def := fieldDefinition(parent, selection.Name.Value)
+			var child graphql.Type
+			if def != nil {
+				child, _ = graphql.GetNamed(def.Type).(graphql.Type)
+			}
+			n, d := c.selectionSet(selection.SelectionSet, child, depth+1, visiting)
+			if d < depth {
+				d = depth
+			}
+			add(1+c.multiplier(selection, def)*n, d)
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if selection.TypeCondition != nil {
+				fragmentType = Schema.Type(selection.TypeCondition.Name.Value)
+			}
+			add(c.selectionSet(selection.SelectionSet, fragmentType, depth, visiting))
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			add(c.selectionSet(fragment.SelectionSet, Schema.Type(fragment.TypeCondition.Name.Value), depth, visiting))
+			delete(visiting, name)
+		}
+	}
+	return cost, maxDepth
+}
+
+// multiplier 子字段的复杂度倍数：带 limit 参数的字段按请求的 limit（或默认值）计算，其他字段为 1
+func (c complexity) multiplier(field *ast.Field, def *graphql.FieldDefinition) int {
+	if def == nil {
+		return 1
+	}
+
+	for _, arg := range def.Args {
+		if arg.Name() != "limit" {
+			continue
+		}
+		n, _ := arg.DefaultValue.(int)
+		for _, value := range field.Arguments {
+			if v, ok := c.intValue(value.Value); ok && value.Name.Value == "limit" {
+				n = v
+			}
+		}
+		if n > 1 {
+			return n
+		}
+	}
+	return 1
+}
+
+// intValue 读取整数字面量或变量的值，变量未提供时返回 false
+func (c complexity) intValue(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := c.variables[value.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// fieldDefinition 查找类型中的字段定义
+func fieldDefinition(parent graphql.Type, name string) *graphql.FieldDefinition {
+	if object, ok := parent.(*graphql.Object); ok {
+		return object.Fields()[name]
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"E-Todo/dto"
+	"context"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Execute 在复杂度限制内执行 GraphQL 请求
+// 解析失败时交由 graphql.Do 返回语法错误
+func Execute(ctx context.Context, req dto.GraphQLReq, limits Limits) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query)})})
+	if err == nil {
+		if appErr := checkComplexity(doc, req.Variables, limits); appErr != nil {
+			return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+				Message:    appErr.Message,
+				Extensions: (&Error{err: appErr}).Extensions(),
+			}}}
+		}
+	}
+
+	return graphql.Do(graphql.Params{
+		Schema:         Schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+}
+
+// 确保 Error 会被写入 extensions
+var _ gqlerrors.ExtendedError = (*Error)(nil)
@@ -0,0 +1,204 @@
+package graph
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/services"
+	"context"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"log"
+)
+
+// defaultPageLimit 未指定 limit 时每页的任务数量，与 REST 接口一致
+const defaultPageLimit = 50
+
+// resolveTasks 查询任务列表，过滤条件与 GET /tasks 一致
+func resolveTasks(p graphql.ResolveParams) (interface{}, error) {
+	req := dto.FetchAllTasksReq{
+		Page:          intArg(p.Args, "page"),
+		Limit:         intArg(p.Args, "limit"),
+		KeyWords:      stringArg(p.Args, "keywords"),
+		Category:      stringArg(p.Args, "category"),
+		Status:        stringArg(p.Args, "status"),
+		Color:         stringArg(p.Args, "color"),
+		RemainingDays: intArg(p.Args, "remainingDays"),
+		Sort:          stringArg(p.Args, "sort"),
+		AsOf:          stringArg(p.Args, "asOf"),
+		Assignee:      stringArg(p.Args, "assignee"),
+		Watcher:       stringArg(p.Args, "watcher"),
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, resolverError(apperrors.Validation(err))
+	}
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultPageLimit
+	}
+
+	tasks, total, err := services.FetchAllTasks(p.Context, req)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	if tasks == nil {
+		tasks = []dto.TaskDTO{}
+	}
+	return dto.FetchAllTasksResp{Tasks: tasks, Total: total, Page: req.Page, Limit: req.Limit}, nil
+}
+
+// resolveTask 查询单个任务，不存在时返回 null
+func resolveTask(p graphql.ResolveParams) (interface{}, error) {
+	task, err := services.GetTask(uint(intArg(p.Args, "id")), nil)
+	if err != nil {
+		if apperrors.From(err).Code == apperrors.CodeNotFound {
+			return nil, nil
+		}
+		return nil, resolverError(err)
+	}
+	return task.TaskDTO, nil
+}
+
+// resolveCreateTask 创建任务
+func resolveCreateTask(p graphql.ResolveParams) (interface{}, error) {
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := dto.CreateTaskReq{
+		Title:       stringArg(input, "title"),
+		Description: stringArg(input, "description"),
+		Category:    stringArg(input, "category"),
+		Color:       stringArg(input, "color"),
+		DueDate:     stringArg(input, "dueDate"),
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, resolverError(apperrors.Validation(err))
+	}
+
+	task, err := services.CreateTask(p.Context, req)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return task, nil
+}
+
+// resolveUpdateTask 更新任务
+func resolveUpdateTask(p graphql.ResolveParams) (interface{}, error) {
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := dto.UpdateTaskReq{
+		ID:          uint(intArg(input, "id")),
+		Title:       stringArg(input, "title"),
+		Description: stringArg(input, "description"),
+		Category:    stringArg(input, "category"),
+		Color:       stringArg(input, "color"),
+		DueDate:     stringArg(input, "dueDate"),
+		Status:      stringArg(input, "status"),
+		Version:     uint(intArg(input, "version")),
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, resolverError(apperrors.Validation(err))
+	}
+
+	task, err := services.UpdateTask(p.Context, req)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return task, nil
+}
+
+// resolveTaskAction 对单个任务执行操作（完成、恢复），成功后返回最新的任务
+func resolveTaskAction(action func(ctx context.Context, id uint, version uint) error) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		id := uint(intArg(p.Args, "id"))
+		if err := action(p.Context, id, uint(intArg(p.Args, "version"))); err != nil {
+			return nil, resolverError(err)
+		}
+
+		task, err := services.GetTask(id, nil)
+		if err != nil {
+			return nil, resolverError(err)
+		}
+		return task.TaskDTO, nil
+	}
+}
+
+// resolveTaskRemoval 删除或软删除任务，删除后任务无法再通过 task 查询，成功时返回 true
+func resolveTaskRemoval(remove func(ctx context.Context, id uint, version uint) error) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if err := remove(p.Context, uint(intArg(p.Args, "id")), uint(intArg(p.Args, "version"))); err != nil {
+			return nil, resolverError(err)
+		}
+		return true, nil
+	}
+}
+
+// resolveBatch 批量任务操作，事务模式中止时错误的 data 扩展字段中包含各任务的处理结果
+func resolveBatch(action func(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		req := dto.BatchTaskActionReq{Atomic: p.Args["atomic"] == true}
+		ids, _ := p.Args["ids"].([]interface{})
+		for _, id := range ids {
+			if n, ok := id.(int); ok && n > 0 {
+				req.IDs = append(req.IDs, uint(n))
+			}
+		}
+		if len(req.IDs) == 0 {
+			return nil, resolverError(apperrors.InvalidField("ids", "is required"))
+		}
+
+		resp, err := action(p.Context, req)
+		if err != nil {
+			return nil, resolverError(apperrors.From(err).WithData(resp))
+		}
+		return resp, nil
+	}
+}
+
+// intArg 读取整数参数，未提供时返回 0
+func intArg(args map[string]interface{}, name string) int {
+	n, _ := args[name].(int)
+	return n
+}
+
+// stringArg 读取字符串参数，未提供时返回空字符串
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+// Error 携带错误码的 GraphQL 错误，错误码等信息放在 extensions 中
+type Error struct {
+	err *apperrors.Error
+}
+
+func (e *Error) Error() string {
+	if e.err.Code == apperrors.CodeInternal {
+		return apperrors.CodeInternal.Title
+	}
+	return e.err.Message
+}
+
+// Extensions 实现 gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code":  e.err.Code.Num,
+		"error": e.err.Code.Name,
+	}
+	if len(e.err.Fields) > 0 {
+		extensions["fields"] = e.err.Fields
+	}
+	if e.err.Data != nil {
+		extensions["data"] = e.err.Data
+	}
+	return extensions
+}
+
+// resolverError 将服务层错误转换为 GraphQL 错误，内部错误只记录日志，不向客户端暴露细节
+func resolverError(err error) error {
+	appErr := apperrors.From(err)
+	if appErr.Code == apperrors.CodeInternal {
+		log.Printf("graphql: %v", err)
+	}
+	return &Error{err: appErr}
+}
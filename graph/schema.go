@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"E-Todo/dto"
+	"E-Todo/services"
+	"github.com/graphql-go/graphql"
+)
+
+// taskType 任务
+var taskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":           taskField(graphql.NewNonNull(graphql.Int), func(t dto.TaskDTO) interface{} { return t.ID }),
+		"title":        taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.Title }),
+		"description":  taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.Description }),
+		"category":     taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.Category }),
+		"color":        taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.Color }),
+		"dueDate":      taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.DueDate }),
+		"status":       taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.Status }),
+		"version":      taskField(graphql.NewNonNull(graphql.Int), func(t dto.TaskDTO) interface{} { return t.Version }),
+		"commentCount": taskField(graphql.NewNonNull(graphql.Int), func(t dto.TaskDTO) interface{} { return t.CommentCount }),
+		"progress": taskField(graphql.Int, func(t dto.TaskDTO) interface{} {
+			if t.Progress == nil {
+				return nil
+			}
+			return *t.Progress
+		}),
+		"createdAt": taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.CreatedAt }),
+		"updatedAt": taskField(graphql.NewNonNull(graphql.String), func(t dto.TaskDTO) interface{} { return t.UpdatedAt }),
+		"deletedAt": taskField(graphql.String, func(t dto.TaskDTO) interface{} {
+			if t.DeletedAt == "" {
+				return nil
+			}
+			return t.DeletedAt
+		}),
+	},
+})
+
+// taskField 从 dto.TaskDTO 中取值的字段
+func taskField(fieldType graphql.Output, get func(t dto.TaskDTO) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(dto.TaskDTO)), nil
+		},
+	}
+}
+
+// taskPageType 分页的任务列表
+var taskPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskPage",
+	Fields: graphql.Fields{
+		"tasks": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType)))},
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"page":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// batchItemResultType 批量操作中单个任务的处理结果
+var batchItemResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BatchItemResult",
+	Fields: graphql.Fields{
+		"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"result": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+// batchResultType 批量任务操作结果
+var batchResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BatchResult",
+	Fields: graphql.Fields{
+		"results":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(batchItemResultType)))},
+		"succeeded": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"failed":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"affected":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// createTaskInputType 创建任务参数
+var createTaskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateTaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"category":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"color":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String), Description: "yyyy-MM-ddTHH:mmZ"},
+	},
+})
+
+// updateTaskInputType 更新任务参数
+var updateTaskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateTaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"id":          &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"category":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"color":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"status":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"version":     &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "Expected task version; omit to skip the check"},
+	},
+})
+
+// versionArgs 单个任务操作的参数
+var versionArgs = graphql.FieldConfigArgument{
+	"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+	"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Expected task version; omit to skip the check"},
+}
+
+// batchArgs 批量任务操作的参数
+var batchArgs = graphql.FieldConfigArgument{
+	"ids":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int)))},
+	"atomic": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+}
+
+// queryType 查询
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"tasks": &graphql.Field{
+			Type: graphql.NewNonNull(taskPageType),
+			Args: graphql.FieldConfigArgument{
+				"page":          &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+				"limit":         &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageLimit},
+				"keywords":      &graphql.ArgumentConfig{Type: graphql.String},
+				"category":      &graphql.ArgumentConfig{Type: graphql.String},
+				"status":        &graphql.ArgumentConfig{Type: graphql.String},
+				"color":         &graphql.ArgumentConfig{Type: graphql.String},
+				"remainingDays": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				"sort":          &graphql.ArgumentConfig{Type: graphql.String, Description: "Field name, prefix with - for descending"},
+				"asOf":          &graphql.ArgumentConfig{Type: graphql.String, Description: "List tasks as they were at this time (RFC 3339)"},
+				"assignee":      &graphql.ArgumentConfig{Type: graphql.String, Description: "User, or me for the caller"},
+				"watcher":       &graphql.ArgumentConfig{Type: graphql.String, Description: "User, or me for the caller"},
+			},
+			Resolve: resolveTasks,
+		},
+		"task": &graphql.Field{
+			Type:    taskType,
+			Args:    graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+			Resolve: resolveTask,
+		},
+	},
+})
+
+// mutationType 变更
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createTask": &graphql.Field{
+			Type:    graphql.NewNonNull(taskType),
+			Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createTaskInputType)}},
+			Resolve: resolveCreateTask,
+		},
+		"updateTask": &graphql.Field{
+			Type:    graphql.NewNonNull(taskType),
+			Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateTaskInputType)}},
+			Resolve: resolveUpdateTask,
+		},
+		"completeTask":         &graphql.Field{Type: graphql.NewNonNull(taskType), Args: versionArgs, Resolve: resolveTaskAction(services.CompleteTask)},
+		"softDeleteTask":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Args: versionArgs, Resolve: resolveTaskRemoval(services.SoftDelete)},
+		"restoreTask":          &graphql.Field{Type: graphql.NewNonNull(taskType), Args: versionArgs, Resolve: resolveTaskAction(services.RestoreTask)},
+		"deleteTask":           &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Args: versionArgs, Resolve: resolveTaskRemoval(services.DeleteTask)},
+		"batchCompleteTasks":   &graphql.Field{Type: graphql.NewNonNull(batchResultType), Args: batchArgs, Resolve: resolveBatch(services.BatchCompleteTasks)},
+		"batchSoftDeleteTasks": &graphql.Field{Type: graphql.NewNonNull(batchResultType), Args: batchArgs, Resolve: resolveBatch(services.BatchSoftDeleteTasks)},
+		"batchRestoreTasks":    &graphql.Field{Type: graphql.NewNonNull(batchResultType), Args: batchArgs, Resolve: resolveBatch(services.BatchRestoreTasks)},
+		"batchDeleteTasks":     &graphql.Field{Type: graphql.NewNonNull(batchResultType), Args: batchArgs, Resolve: resolveBatch(services.BatchDeleteTasks)},
+	},
+})
+
+// Schema 任务领域的 GraphQL Schema
+var Schema graphql.Schema
+
+func init() {
+	var err error
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	if err != nil {
+		panic(err)
+	}
+}
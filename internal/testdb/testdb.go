@@ -0,0 +1,61 @@
+// Package testdb 为测试提供内存中的 SQLite 数据库，替代 MySQL
+package testdb
+
+import (
+	"E-Todo/config"
+	"E-Todo/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+// tasksTable SQLite 不支持 enum，tasks 表按 migrations 中的结构手动创建
+const tasksTable = `CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT,
+	description TEXT,
+	category TEXT,
+	color TEXT,
+	due_date DATETIME,
+	status TEXT DEFAULT 'pending',
+	version INTEGER NOT NULL DEFAULT 1,
+	comment_count INTEGER NOT NULL DEFAULT 0,
+	checklist_total INTEGER NOT NULL DEFAULT 0,
+	checklist_checked INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME,
+	updated_at DATETIME,
+	deleted_at DATETIME
+)`
+
+// Open 创建一个空的内存数据库并设置为 config.DB，测试结束后关闭
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// 内存数据库只在单个连接内可见
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.Exec(tasksTable).Error; err != nil {
+		t.Fatalf("failed to create tasks table: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.TaskEvent{}, &models.TaskHistory{}, &models.TaskOperation{}, &models.TaskOperationItem{}, &models.TaskVersion{},
+		&models.Webhook{}, &models.WebhookDelivery{}, &models.IdempotencyKey{},
+		&models.Comment{}, &models.Attachment{}, &models.BlobDeletion{}, &models.ChecklistItem{},
+		&models.TaskMember{}, &models.Notification{}, &models.Reminder{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	config.DB = db
+	return db
+}
@@ -3,12 +3,43 @@ package main
 import (
 	"E-Todo/config"
 	"E-Todo/routes"
+	"E-Todo/rpc"
+	"E-Todo/services"
+	"time"
 )
 
 func main() {
 	// 初始化数据库连接
 	config.InitDB()
+	// 初始化附件存储
+	config.InitBlobStore()
+	// 启动回收站自动清理任务
+	services.StartTrashPurgeJob(config.TrashRetentionDays(), time.Hour)
+	// 启动过期幂等键清理任务
+	services.StartIdempotencyKeyCleanup(time.Hour)
+	// 启动任务变更事件的发布、实时推送及过期事件清理
+	if config.LogTaskEvents() {
+		services.RegisterEventSink("log", services.LogEventSink)
+	}
+	services.StartOutboxDispatcher(500 * time.Millisecond)
+	services.StartTaskEventTail(500 * time.Millisecond)
+	services.StartTaskEventCleanup(config.TaskEventRetentionDays(), time.Hour)
+	// 启动过期任务操作清理，超过可撤销时长的操作不再保留快照
+	services.StartTaskOperationCleanup(config.UndoWindow(), time.Hour)
+	// 启动已删除附件的内容清理
+	services.StartBlobCleanup(time.Minute)
+	// 启动提醒发送
+	if config.LogReminders() {
+		services.RegisterReminderChannel("log", services.LogReminderChannel)
+	}
+	services.StartReminderScheduler(15 * time.Second)
+	// 启动 Webhook 投递
+	services.StartWebhookDispatcher(time.Second, config.WebhookMaxAttempts())
 	r := routes.SetupRouter()
+	// 启动 gRPC 服务
+	if addr := config.GRPCAddr(); addr != "" {
+		rpc.Start(addr)
+	}
 	// 启动服务器
 	err := r.Run(":8080")
 	if err != nil {
@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/services"
+	"E-Todo/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader 幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength 幂等键最大长度
+const maxIdempotencyKeyLength = 255
+
+// bodyRecorder 在写出响应的同时记录响应体
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 幂等键中间件
+// 携带 Idempotency-Key 的请求首次处理后保存响应，在 ttl 内使用相同键和相同请求重试时直接重放；
+// 相同键但请求内容不同时拒绝。幂等键按 userHeader 中的调用方区分。未携带该请求头的请求不受影响。
+func Idempotency(ttl time.Duration, userHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := c.GetHeader(userHeader)
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.Error(c, apperrors.InvalidField(IdempotencyKeyHeader, "is too long"))
+			c.Abort()
+			return
+		}
+
+		// 读取请求体用于计算摘要，并放回供后续处理器绑定
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.BindError(c, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, err := services.BeginIdempotentRequest(caller, key, requestHash, ttl)
+		if err != nil {
+			utils.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		// 重放首次请求的响应
+		if record != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// 未保存响应时（服务端错误、处理器 panic、保存失败）删除记录，允许客户端使用同一个键重试
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := services.AbandonIdempotentRequest(caller, key); err != nil {
+				log.Printf("Idempotency: %v", err)
+			}
+		}()
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if err = services.CompleteIdempotentRequest(caller, key, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Idempotency: %v", err)
+			return
+		}
+		completed = true
+	}
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"E-Todo/internal/testdb"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// idempotentRouter 使用幂等键中间件的路由，处理器在 fail 为 true 时 panic，返回处理次数
+func idempotentRouter(t *testing.T, fail *bool) (*gin.Engine, *int) {
+	testdb.Open(t)
+	gin.SetMode(gin.TestMode)
+	calls := new(int)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	r.POST("/tasks", Idempotency(time.Hour, "X-User"), func(c *gin.Context) {
+		*calls++
+		if *fail {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": *calls})
+	})
+	return r, calls
+}
+
+// postIdempotent 以 user 的身份携带幂等键发送请求
+func postIdempotent(r *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotencyPanicReleasesKey 处理器 panic 后幂等键不会停留在处理中，可以使用同一个键重试
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	fail := true
+	r, calls := idempotentRouter(t, &fail)
+
+	if w := postIdempotent(r, "alice", "k1", `{"title":"a"}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request: status %d", w.Code)
+	}
+	fail = false
+	w := postIdempotent(r, "alice", "k1", `{"title":"a"}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry after panic: status %d, body %s", w.Code, w.Body)
+	}
+	w = postIdempotent(r, "alice", "k1", `{"title":"a"}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("second retry should replay: status %d, body %s", w.Code, w.Body)
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
+
+// TestIdempotencyScopedByCaller 不同调用方使用相同的幂等键互不影响
+func TestIdempotencyScopedByCaller(t *testing.T) {
+	fail := false
+	r, calls := idempotentRouter(t, &fail)
+
+	for _, user := range []string{"alice", "bob", ""} {
+		w := postIdempotent(r, user, "k1", `{"title":"`+user+`"}`)
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Fatalf("caller %q: status %d, body %s", user, w.Code, w.Body)
+		}
+	}
+	if *calls != 3 {
+		t.Errorf("handler called %d times, want 3", *calls)
+	}
+
+	// 同一调用方使用相同的键发送不同的请求仍被拒绝
+	if w := postIdempotent(r, "bob", "k1", `{"title":"other"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"E-Todo/services"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"regexp"
+)
+
+// RequestIDHeader 请求 ID 请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey 当前请求的请求 ID 在 gin.Context 中的键
+const RequestIDKey = "request_id"
+
+// validRequestID 客户端提供的请求 ID 的格式，不符合时重新生成
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestContext 为请求分配请求 ID（沿用客户端提供的 X-Request-ID），并将请求 ID 与 userHeader 中的调用方标识
+// 放入请求的 context，经由该 context 发起的任务变更会记录调用方和请求 ID
+func RequestContext(userHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := services.WithCaller(c.Request.Context(), c.GetHeader(userHeader), requestID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// newRequestID 生成随机的请求 ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
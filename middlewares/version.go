@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersionKey 当前请求的 API 版本在 gin.Context 中的键
+const APIVersionKey = "api_version"
+
+// APIVersion 记录请求使用的 API 版本，处理器可通过 c.GetString(APIVersionKey) 区分版本
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(APIVersionKey, version)
+		c.Header("API-Version", version)
+		c.Next()
+	}
+}
+
+// Deprecated 为已弃用的路由添加 Deprecation、Sunset (RFC 8594) 和指向新版本的 Link 响应头
+// prefix 为旧路由前缀，successor 为替代路由前缀；sunset 为零值时不返回 Sunset 头
+func Deprecated(prefix, successor string, sunset time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		path := successor + strings.TrimPrefix(c.Request.URL.Path, prefix)
+		c.Header("Link", "<"+path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
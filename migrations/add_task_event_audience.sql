@@ -0,0 +1,3 @@
+-- 可见该事件的用户（JSON 数组），为空表示所有人可见；已有事件对所有人可见
+ALTER TABLE task_events
+    ADD COLUMN audience TEXT;
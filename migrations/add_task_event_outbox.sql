@@ -0,0 +1,9 @@
+ALTER TABLE task_events
+    ADD COLUMN published_at TIMESTAMP NULL,                  -- 发布时间，为空表示待发布
+    ADD COLUMN claimed_until TIMESTAMP NULL,                 -- 发布中的占用期限，发布失败时为下次重试时间
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,              -- 发布失败次数
+    ADD COLUMN last_error VARCHAR(1000),                     -- 最近一次发布失败的错误
+    ADD INDEX idx_task_events_published_at (published_at);
+
+-- 已有事件视为已发布，避免上线后重复推送
+UPDATE task_events SET published_at = created_at;
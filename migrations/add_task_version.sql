@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER status; -- 乐观锁版本号，每次修改加 1
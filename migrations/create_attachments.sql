@@ -0,0 +1,24 @@
+CREATE TABLE attachments (
+                       id INT AUTO_INCREMENT PRIMARY KEY,                 -- 附件 ID
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       name VARCHAR(255) NOT NULL,                        -- 上传时的文件名
+                       size BIGINT NOT NULL,                              -- 字节数
+                       mime_type VARCHAR(255),                            -- 内容类型
+                       checksum VARCHAR(64),                              -- 内容的 SHA-256，十六进制
+                       blob_key VARCHAR(255) NOT NULL,                    -- 内容在存储中的 key
+                       uploader VARCHAR(255),                             -- 上传者（X-User）
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 上传时间
+                       INDEX idx_attachments_task_id (task_id),
+                       UNIQUE INDEX idx_attachments_blob_key (blob_key)
+);
+
+-- 待从存储中删除的附件内容，与附件记录的删除在同一事务中写入
+CREATE TABLE blob_deletions (
+                       id BIGINT AUTO_INCREMENT PRIMARY KEY,
+                       blob_key VARCHAR(255) NOT NULL,                    -- 内容在存储中的 key
+                       attempts INT NOT NULL DEFAULT 0,                   -- 已失败次数
+                       next_attempt_at TIMESTAMP NOT NULL,                -- 下次删除时间
+                       last_error VARCHAR(1000),                          -- 最近一次失败的原因
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                       INDEX idx_blob_deletions_next_attempt_at (next_attempt_at)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE checklist_items (
+                       id INT AUTO_INCREMENT PRIMARY KEY,                 -- 检查项 ID
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       text VARCHAR(500) NOT NULL,                        -- 检查项内容
+                       checked BOOLEAN NOT NULL DEFAULT FALSE,            -- 是否已勾选
+                       position INT NOT NULL,                             -- 从 0 开始的序号
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 创建时间
+                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- 更新时间
+                       INDEX idx_checklist_items_task_id (task_id)
+);
+
+ALTER TABLE tasks
+    ADD COLUMN checklist_total INT NOT NULL DEFAULT 0 AFTER comment_count,      -- 检查项总数
+    ADD COLUMN checklist_checked INT NOT NULL DEFAULT 0 AFTER checklist_total;  -- 已勾选的检查项数
@@ -0,0 +1,14 @@
+CREATE TABLE comments (
+                       id INT AUTO_INCREMENT PRIMARY KEY,                 -- 评论 ID
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       parent_id INT NULL,                                -- 回复的评论 ID，顶层评论为空
+                       author VARCHAR(255),                               -- 作者（X-User）
+                       body TEXT NOT NULL,                                -- 评论内容，Markdown 格式
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 创建时间
+                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- 更新时间
+                       INDEX idx_comments_task_id (task_id),
+                       INDEX idx_comments_parent_id (parent_id)
+);
+
+ALTER TABLE tasks
+    ADD COLUMN comment_count INT NOT NULL DEFAULT 0 AFTER version;      -- 评论数
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+                       id INT AUTO_INCREMENT PRIMARY KEY,                 -- 记录 ID
+                       caller VARCHAR(255) NOT NULL DEFAULT '',           -- 调用方标识（X-User），幂等键按调用方区分
+                       `key` VARCHAR(255) NOT NULL,                       -- 客户端提供的 Idempotency-Key
+                       request_hash CHAR(64) NOT NULL,                    -- 请求方法、路径和请求体的 SHA-256
+                       completed BOOLEAN NOT NULL DEFAULT FALSE,          -- 首次请求是否已处理完成
+                       status_code INT,                                   -- 首次请求的 HTTP 状态码
+                       content_type VARCHAR(100),                         -- 首次请求的响应类型
+                       response_body MEDIUMBLOB,                          -- 首次请求的响应体
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 创建时间
+                       expires_at DATETIME NOT NULL,                      -- 过期时间
+                       UNIQUE KEY idx_idempotency_keys_caller_key (caller, `key`),
+                       INDEX idx_idempotency_keys_expires_at (expires_at)
+);
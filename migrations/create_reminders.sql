@@ -0,0 +1,24 @@
+CREATE TABLE reminders (
+                       id INT AUTO_INCREMENT PRIMARY KEY,
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       remind_at DATETIME NULL,                           -- 绝对提醒时间，相对截止时间的提醒为空
+                       offset_minutes INT NULL,                           -- 相对截止时间的分钟数，负数表示截止前
+                       note VARCHAR(500),                                 -- 提醒内容
+                       fire_at DATETIME NOT NULL,                         -- 实际触发时间
+                       status VARCHAR(20) NOT NULL DEFAULT 'pending',     -- pending / sent / skipped / failed
+                       attempts INT NOT NULL DEFAULT 0,                   -- 已失败次数
+                       claimed_until DATETIME NULL,                       -- 发送中的占用期限或下次重试时间
+                       sent_channels VARCHAR(500),                        -- 本次触发已发送成功的渠道，逗号分隔
+                       last_error VARCHAR(1000),                          -- 最近一次发送失败的错误
+                       fired_at DATETIME NULL,                            -- 发送或跳过的时间
+                       created_by VARCHAR(255),                           -- 创建者
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                       INDEX idx_reminders_task_id (task_id),
+                       INDEX idx_reminders_status_fire_at (status, fire_at)
+);
+
+-- 为已有的未完成且未到期的任务添加截止时提醒
+INSERT INTO reminders (task_id, offset_minutes, fire_at, status, created_by)
+SELECT id, 0, due_date, 'pending', 'system'
+FROM tasks
+WHERE status = 'pending' AND deleted_at IS NULL AND due_date > NOW();
@@ -0,0 +1,9 @@
+CREATE TABLE task_events (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 事件 ID，单调递增，用于断线续传
+                       type VARCHAR(50) NOT NULL,                         -- 事件类型，如 task.created
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       payload TEXT NOT NULL,                             -- 变更后的任务（硬删除为删除前的任务），JSON
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 事件时间
+                       INDEX idx_task_events_task_id (task_id),
+                       INDEX idx_task_events_created_at (created_at)
+);
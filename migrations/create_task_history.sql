@@ -0,0 +1,13 @@
+CREATE TABLE task_histories (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 记录 ID
+                       task_id INT NOT NULL,                              -- 任务 ID，任务被硬删除后保留
+                       action VARCHAR(50) NOT NULL,                       -- 变更类型，与事件类型一致，如 task.updated
+                       field VARCHAR(50) NOT NULL,                        -- 变更的字段
+                       old_value TEXT,                                    -- 变更前的值
+                       new_value TEXT,                                    -- 变更后的值
+                       actor VARCHAR(255),                                -- 调用方标识（X-User）
+                       request_id VARCHAR(64),                            -- 请求 ID（X-Request-ID）
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 变更时间
+                       INDEX idx_task_histories_task_id (task_id),
+                       INDEX idx_task_histories_request_id (request_id)
+);
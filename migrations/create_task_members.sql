@@ -0,0 +1,23 @@
+CREATE TABLE task_members (
+                       id INT AUTO_INCREMENT PRIMARY KEY,
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       user VARCHAR(255) NOT NULL,                        -- 用户标识（X-User）
+                       role VARCHAR(20) NOT NULL,                         -- assignee 负责人 / watcher 关注者
+                       added_by VARCHAR(255),                             -- 添加者
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                       UNIQUE INDEX idx_task_members_task_user_role (task_id, user, role),
+                       INDEX idx_task_members_user (user)
+);
+
+CREATE TABLE notifications (
+                       id BIGINT AUTO_INCREMENT PRIMARY KEY,
+                       recipient VARCHAR(255) NOT NULL,                   -- 接收通知的用户
+                       type VARCHAR(50) NOT NULL,                         -- mentioned 被提及 / assigned 被设为负责人
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       comment_id INT NULL,                               -- 在评论中提及时为评论 ID
+                       actor VARCHAR(255),                                -- 触发通知的用户
+                       excerpt VARCHAR(1000),                             -- 提及所在文本的摘要
+                       read_at TIMESTAMP NULL,                            -- 已读时间
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                       INDEX idx_notifications_recipient (recipient)
+);
@@ -0,0 +1,26 @@
+CREATE TABLE task_operations (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 操作 ID
+                       action VARCHAR(50) NOT NULL,                       -- 操作类型，与事件类型一致；撤销操作为 task.undo
+                       task_count INT NOT NULL,                           -- 涉及的任务数量
+                       actor VARCHAR(255),                                -- 调用方标识（X-User）
+                       request_id VARCHAR(64),                            -- 请求 ID（X-Request-ID）
+                       undo_of BIGINT UNSIGNED NULL,                      -- 撤销操作所撤销的操作 ID
+                       undone_at TIMESTAMP NULL,                          -- 被撤销的时间
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 操作时间
+                       INDEX idx_task_operations_actor (actor),
+                       INDEX idx_task_operations_request_id (request_id),
+                       INDEX idx_task_operations_created_at (created_at)
+);
+
+CREATE TABLE task_operation_items (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 记录 ID
+                       operation_id BIGINT UNSIGNED NOT NULL,             -- 操作 ID
+                       task_id INT NOT NULL,                              -- 任务 ID
+                       snapshot TEXT,                                     -- 操作前任务的 JSON 快照，创建任务时为空
+                       after_version INT UNSIGNED NOT NULL DEFAULT 0,     -- 操作后的任务版本，硬删除时为 0
+                       INDEX idx_task_operation_items_operation_id (operation_id)
+);
+
+ALTER TABLE task_histories
+    ADD COLUMN operation_id BIGINT UNSIGNED NULL AFTER task_id,     -- 所属的操作
+    ADD INDEX idx_task_histories_operation_id (operation_id);
@@ -0,0 +1,27 @@
+CREATE TABLE task_versions (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 版本 ID
+                       task_id INT NOT NULL,                              -- 任务 ID，任务被硬删除后保留
+                       title VARCHAR(255) NOT NULL,                       -- 任务标题
+                       description TEXT,                                  -- 任务描述
+                       category VARCHAR(100),                             -- 任务分类
+                       color VARCHAR(20),                                 -- 颜色标记
+                       due_date DATETIME,                                 -- 截止日期
+                       status VARCHAR(20),                                -- 任务状态
+                       version INT UNSIGNED NOT NULL,                     -- 任务的乐观锁版本号
+                       created_at TIMESTAMP NULL,                         -- 任务的创建时间
+                       updated_at TIMESTAMP NULL,                         -- 任务的更新时间
+                       deleted_at TIMESTAMP NULL,                         -- 任务的软删除时间
+                       valid_from TIMESTAMP(6) NOT NULL,                  -- 版本生效时间
+                       valid_to TIMESTAMP(6) NULL,                        -- 版本失效时间，为空表示当前版本
+                       INDEX idx_task_versions_task_id (task_id),
+                       INDEX idx_task_versions_valid_from (valid_from),
+                       INDEX idx_task_versions_valid_to (valid_to)
+);
+
+-- 已有任务没有变更记录，当前状态只能确定自最后一次修改（或软删除）起有效，不能回溯到创建时间；
+-- 更早的时刻没有版本，按该时刻查询时不返回这些任务
+INSERT INTO task_versions (task_id, title, description, category, color, due_date, status, version, created_at, updated_at, deleted_at, valid_from)
+SELECT id, title, description, category, color, due_date, status, version, created_at, updated_at, deleted_at,
+       GREATEST(COALESCE(updated_at, created_at, CURRENT_TIMESTAMP(6)),
+                COALESCE(deleted_at, updated_at, created_at, CURRENT_TIMESTAMP(6)))
+FROM tasks;
@@ -0,0 +1,27 @@
+CREATE TABLE webhooks (
+                       id INT AUTO_INCREMENT PRIMARY KEY,                 -- 订阅 ID
+                       url VARCHAR(2048) NOT NULL,                        -- 推送地址
+                       secret VARCHAR(255) NOT NULL,                      -- HMAC-SHA256 签名密钥
+                       event_types VARCHAR(500) NOT NULL,                 -- 订阅的事件类型，逗号分隔
+                       active BOOLEAN NOT NULL DEFAULT TRUE,              -- 是否启用
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 创建时间
+                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP -- 更新时间
+);
+
+CREATE TABLE webhook_deliveries (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,     -- 投递 ID
+                       webhook_id INT NOT NULL,                           -- 订阅 ID
+                       event_id BIGINT UNSIGNED NOT NULL,                 -- 任务变更事件 ID
+                       event_type VARCHAR(50) NOT NULL,                   -- 事件类型
+                       payload TEXT NOT NULL,                             -- 推送的请求体，JSON
+                       status VARCHAR(20) NOT NULL,                       -- 投递状态：pending / succeeded / failed
+                       attempts INT NOT NULL DEFAULT 0,                   -- 已尝试次数
+                       next_attempt_at TIMESTAMP NULL,                    -- 下次尝试时间
+                       response_status INT,                               -- 最近一次投递的 HTTP 状态码
+                       last_error VARCHAR(1000),                          -- 最近一次投递的错误
+                       delivered_at TIMESTAMP NULL,                       -- 投递成功的时间
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,    -- 创建时间
+                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- 更新时间
+                       UNIQUE INDEX idx_webhook_deliveries_event (webhook_id, event_id),
+                       INDEX idx_webhook_deliveries_due (status, next_attempt_at)
+);
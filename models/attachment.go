@@ -0,0 +1,106 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Attachment 任务附件的元数据，文件内容保存在 BlobStore 中
+type Attachment struct {
+	ID        uint      `gorm:"primaryKey"`
+	TaskID    uint      `gorm:"not null;index"`
+	Name      string    `gorm:"size:255;not null"` // 上传时的文件名
+	Size      int64     `gorm:"not null"`
+	MimeType  string    `gorm:"size:255"`
+	Checksum  string    `gorm:"size:64"`                  // 内容的 SHA-256，十六进制
+	BlobKey   string    `gorm:"size:255;not null;unique"` // 内容在 BlobStore 中的 key
+	Uploader  string    `gorm:"size:255"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// BlobDeletion 待删除的附件内容，与附件记录在同一事务中写入，由后台任务删除
+type BlobDeletion struct {
+	ID            uint64    `gorm:"primaryKey"`
+	BlobKey       string    `gorm:"size:255;not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index"`
+	LastError     string    `gorm:"size:1000"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// Create 保存附件记录
+func (a *Attachment) Create(tx *gorm.DB) error {
+	return tx.Create(a).Error
+}
+
+// FetchByTask 按上传时间顺序获取任务的附件
+func (a *Attachment) FetchByTask(db *gorm.DB, taskID uint) ([]Attachment, error) {
+	var attachments []Attachment
+	err := db.Where("task_id = ?", taskID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+// FetchByID 查询任务下的附件
+func (a *Attachment) FetchByID(db *gorm.DB, taskID, id uint) error {
+	return db.Where("task_id = ?", taskID).First(a, id).Error
+}
+
+// Delete 删除附件记录，并将其内容加入待删除队列
+func (a *Attachment) Delete(tx *gorm.DB) error {
+	if err := tx.Delete(a).Error; err != nil {
+		return err
+	}
+	return enqueueBlobDeletions(tx, []string{a.BlobKey})
+}
+
+// deleteTaskAttachments 删除任务的全部附件记录，并将其内容加入待删除队列，在硬删除任务时调用
+func deleteTaskAttachments(tx *gorm.DB, taskIDs []uint) error {
+	var keys []string
+	if err := tx.Model(&Attachment{}).Where("task_id IN ?", taskIDs).Pluck("blob_key", &keys).Error; err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := tx.Where("task_id IN ?", taskIDs).Delete(&Attachment{}).Error; err != nil {
+		return err
+	}
+	return enqueueBlobDeletions(tx, keys)
+}
+
+// enqueueBlobDeletions 将附件内容加入待删除队列，立即可删除
+func enqueueBlobDeletions(tx *gorm.DB, keys []string) error {
+	now := time.Now()
+	deletions := make([]BlobDeletion, 0, len(keys))
+	for _, key := range keys {
+		deletions = append(deletions, BlobDeletion{BlobKey: key, NextAttemptAt: now})
+	}
+	return tx.Create(&deletions).Error
+}
+
+// Create 将内容加入待删除队列，立即可删除，用于清理未能保存记录的上传
+func (d *BlobDeletion) Create(db *gorm.DB) error {
+	d.NextAttemptAt = time.Now()
+	return db.Create(d).Error
+}
+
+// FetchDue 获取到期的待删除内容
+func (d *BlobDeletion) FetchDue(db *gorm.DB, now time.Time, limit int) ([]BlobDeletion, error) {
+	var deletions []BlobDeletion
+	err := db.Where("next_attempt_at <= ?", now).Order("id").Limit(limit).Find(&deletions).Error
+	return deletions, err
+}
+
+// Done 内容已删除，移出队列
+func (d *BlobDeletion) Done(db *gorm.DB) error {
+	return db.Delete(d).Error
+}
+
+// MarkFailed 记录删除失败，retryAt 之后重试
+func (d *BlobDeletion) MarkFailed(db *gorm.DB, msg string, retryAt time.Time) error {
+	return db.Model(d).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      msg,
+		"next_attempt_at": retryAt,
+	}).Error
+}
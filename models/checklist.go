@@ -0,0 +1,137 @@
+package models
+
+import (
+	"E-Todo/config"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ErrChecklistIncomplete 开启 CHECKLIST_REQUIRED_FOR_COMPLETION 时，任务仍有未勾选的检查项，不能完成
+var ErrChecklistIncomplete = errors.New("checklist has unchecked items")
+
+// ChecklistItem 任务检查清单中的一项，Position 为从 0 开始的连续序号
+type ChecklistItem struct {
+	ID        uint      `gorm:"primaryKey"`
+	TaskID    uint      `gorm:"not null;index"`
+	Text      string    `gorm:"size:500;not null"`
+	Checked   bool      `gorm:"not null;default:false"`
+	Position  int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Create 将检查项插入到 Position 处，其后的检查项依次后移；Position 小于 0 或超出末尾时追加到末尾
+// 需在锁定任务的事务中调用
+func (c *ChecklistItem) Create(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&ChecklistItem{}).Where("task_id = ?", c.TaskID).Count(&count).Error; err != nil {
+		return err
+	}
+	if c.Position < 0 || c.Position > int(count) {
+		c.Position = int(count)
+	}
+
+	err := tx.Model(&ChecklistItem{}).Where("task_id = ? AND position >= ?", c.TaskID, c.Position).
+		UpdateColumn("position", gorm.Expr("position + 1")).Error
+	if err != nil {
+		return err
+	}
+	if err = tx.Create(c).Error; err != nil {
+		return err
+	}
+	return addChecklistCounts(tx, c.TaskID, 1, boolToInt(c.Checked))
+}
+
+// FetchByTask 按顺序获取任务的全部检查项
+func (c *ChecklistItem) FetchByTask(db *gorm.DB, taskID uint) ([]ChecklistItem, error) {
+	var items []ChecklistItem
+	err := db.Where("task_id = ?", taskID).Order("position").Find(&items).Error
+	return items, err
+}
+
+// FetchByID 查询任务下的检查项
+func (c *ChecklistItem) FetchByID(db *gorm.DB, taskID, id uint) error {
+	return db.Where("task_id = ?", taskID).First(c, id).Error
+}
+
+// Update 修改检查项的内容或勾选状态，为 nil 的字段保持不变
+func (c *ChecklistItem) Update(tx *gorm.DB, text *string, checked *bool) error {
+	values := map[string]interface{}{}
+	if text != nil {
+		values["text"] = *text
+	}
+	checkedDelta := 0
+	if checked != nil {
+		values["checked"] = *checked
+		checkedDelta = boolToInt(*checked) - boolToInt(c.Checked)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(c).Updates(values).Error; err != nil {
+		return err
+	}
+	if text != nil {
+		c.Text = *text
+	}
+	if checked != nil {
+		c.Checked = *checked
+	}
+	if checkedDelta == 0 {
+		return nil
+	}
+	return addChecklistCounts(tx, c.TaskID, 0, checkedDelta)
+}
+
+// Delete 删除检查项，其后的检查项依次前移
+func (c *ChecklistItem) Delete(tx *gorm.DB) error {
+	if err := tx.Delete(c).Error; err != nil {
+		return err
+	}
+	err := tx.Model(&ChecklistItem{}).Where("task_id = ? AND position > ?", c.TaskID, c.Position).
+		UpdateColumn("position", gorm.Expr("position - 1")).Error
+	if err != nil {
+		return err
+	}
+	return addChecklistCounts(tx, c.TaskID, -1, -boolToInt(c.Checked))
+}
+
+// Reorder 按 ids 的顺序重新排列任务的检查项，ids 需包含任务的全部检查项
+func (c *ChecklistItem) Reorder(tx *gorm.DB, taskID uint, ids []uint) error {
+	for position, id := range ids {
+		err := tx.Model(&ChecklistItem{}).Where("id = ? AND task_id = ?", id, taskID).
+			UpdateColumn("position", position).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checklistIncomplete 是否因检查项未全部勾选而不能完成任务
+func (t *Task) checklistIncomplete() bool {
+	return config.ChecklistRequiredForCompletion() && t.ChecklistChecked < t.ChecklistTotal
+}
+
+// addChecklistCounts 调整任务的检查项总数和已勾选数，不修改任务的版本号和更新时间
+// 调用方需先以 FOR UPDATE 锁定任务行，与 Complete 中的完成条件检查互斥
+func addChecklistCounts(tx *gorm.DB, taskID uint, total, checked int) error {
+	return tx.Unscoped().Model(&Task{}).Where("id = ?", taskID).UpdateColumns(map[string]interface{}{
+		"checklist_total":   gorm.Expr("checklist_total + ?", total),
+		"checklist_checked": gorm.Expr("checklist_checked + ?", checked),
+	}).Error
+}
+
+// deleteTaskChecklist 删除任务的全部检查项，在硬删除任务时调用
+func deleteTaskChecklist(tx *gorm.DB, taskIDs []uint) error {
+	return tx.Where("task_id IN ?", taskIDs).Delete(&ChecklistItem{}).Error
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Comment 任务评论，ParentID 不为空时为对另一条评论的回复
+type Comment struct {
+	ID        uint      `gorm:"primaryKey"`
+	TaskID    uint      `gorm:"not null;index"`
+	ParentID  *uint     `gorm:"index"`
+	Author    string    `gorm:"size:255"`
+	Body      string    `gorm:"type:text;not null"` // Markdown 格式
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Create 保存评论并增加任务的评论数
+func (c *Comment) Create(tx *gorm.DB) error {
+	if err := tx.Create(c).Error; err != nil {
+		return err
+	}
+	if err := addCommentCount(tx, c.TaskID, 1); err != nil {
+		return err
+	}
+	return recordMentions(tx, c.TaskID, &c.ID, "", c.Body)
+}
+
+// FetchByTask 按时间顺序获取任务的全部评论
+func (c *Comment) FetchByTask(db *gorm.DB, taskID uint) ([]Comment, error) {
+	var comments []Comment
+	err := db.Where("task_id = ?", taskID).Order("id").Find(&comments).Error
+	return comments, err
+}
+
+// FetchByID 查询任务下的评论
+func (c *Comment) FetchByID(db *gorm.DB, taskID, id uint) error {
+	return db.Where("task_id = ?", taskID).First(c, id).Error
+}
+
+// UpdateBody 修改评论内容，新增的 @ 提及会通知被提及的用户
+func (c *Comment) UpdateBody(tx *gorm.DB, body string) error {
+	if err := tx.Model(c).Update("body", body).Error; err != nil {
+		return err
+	}
+	before := c.Body
+	c.Body = body
+	return recordMentions(tx, c.TaskID, &c.ID, before, body)
+}
+
+// Delete 删除评论及其全部回复，并减少任务的评论数，返回删除的评论数
+func (c *Comment) Delete(tx *gorm.DB) (int64, error) {
+	ids := []uint{c.ID}
+	for parents := ids; len(parents) > 0; {
+		var replies []uint
+		if err := tx.Model(&Comment{}).Where("parent_id IN ?", parents).Pluck("id", &replies).Error; err != nil {
+			return 0, err
+		}
+		ids = append(ids, replies...)
+		parents = replies
+	}
+
+	result := tx.Where("id IN ?", ids).Delete(&Comment{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, addCommentCount(tx, c.TaskID, -int(result.RowsAffected))
+}
+
+// addCommentCount 调整任务的评论数，不修改任务的版本号和更新时间
+func addCommentCount(tx *gorm.DB, taskID uint, delta int) error {
+	return tx.Unscoped().Model(&Task{}).Where("id = ?", taskID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+// deleteTaskComments 删除任务的全部评论，在硬删除任务时调用
+func deleteTaskComments(tx *gorm.DB, taskIDs []uint) error {
+	return tx.Where("task_id IN ?", taskIDs).Delete(&Comment{}).Error
+}
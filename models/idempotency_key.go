@@ -0,0 +1,59 @@
+package models
+
+import (
+	"E-Todo/config"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// IdempotencyKey 幂等键记录，保存首次请求的响应以便重试时重放
+// 幂等键按调用方区分，不同调用方使用相同的键互不影响
+type IdempotencyKey struct {
+	ID           uint   `gorm:"primaryKey"`
+	Caller       string `gorm:"size:255;not null;default:'';uniqueIndex:idx_idempotency_keys_caller_key"` // 调用方标识（X-User），未提供时为空
+	Key          string `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_caller_key"`
+	RequestHash  string `gorm:"size:64;not null"` // 请求方法、路径和请求体的 SHA-256
+	Completed    bool   `gorm:"not null;default:false"`
+	StatusCode   int
+	ContentType  string `gorm:"size:100"`
+	ResponseBody []byte
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	ExpiresAt    time.Time `gorm:"index"`
+}
+
+// FindByKey 根据调用方和幂等键查询记录
+func (k *IdempotencyKey) FindByKey(caller, key string) error {
+	return config.DB.Where("caller = ? AND `key` = ?", caller, key).First(k).Error
+}
+
+// CreateIfAbsent 创建幂等键记录，键已存在时返回 false
+func (k *IdempotencyKey) CreateIfAbsent() (bool, error) {
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(k)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 保存请求的响应并标记为已完成
+func (k *IdempotencyKey) Complete(statusCode int, contentType string, body []byte) error {
+	return config.DB.Model(k).Updates(map[string]interface{}{
+		"completed":     true,
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+// Delete 删除幂等键记录
+func (k *IdempotencyKey) Delete() error {
+	return config.DB.Delete(k).Error
+}
+
+// PurgeExpired 删除所有已过期的幂等键记录
+func (k *IdempotencyKey) PurgeExpired(now time.Time) (int64, error) {
+	result := config.DB.Where("expires_at < ?", now).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// Expired 判断记录是否已过期
+func (k *IdempotencyKey) Expired(now time.Time) bool {
+	return now.After(k.ExpiresAt)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+	"unicode/utf8"
+)
+
+// 通知类型
+const (
+	NotificationMentioned = "mentioned" // 在任务描述或评论中被 @ 提及
+	NotificationAssigned  = "assigned"  // 被设为任务的负责人
+	NotificationReminder  = "reminder"  // 任务提醒到期
+)
+
+// notificationExcerptSize 通知中保存的原文摘要的最大字符数
+const notificationExcerptSize = 200
+
+// Notification 发给某个用户的站内通知
+type Notification struct {
+	ID        uint64     `gorm:"primaryKey"`
+	Recipient string     `gorm:"size:255;not null;index:idx_notifications_recipient"`
+	Type      string     `gorm:"size:50;not null"`
+	TaskID    uint       `gorm:"not null"`
+	CommentID *uint      // 在评论中提及时为评论 ID
+	Actor     string     `gorm:"size:255"`  // 触发通知的用户
+	Excerpt   string     `gorm:"size:1000"` // 提及所在文本或提醒内容的摘要
+	ReadAt    *time.Time // 已读时间，未读时为空
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// Create 保存通知
+func (n *Notification) Create(db *gorm.DB) error {
+	return db.Create(n).Error
+}
+
+// FetchByRecipient 按时间倒序分页获取用户的通知，unreadOnly 为 true 时只返回未读通知
+func (n *Notification) FetchByRecipient(db *gorm.DB, recipient string, unreadOnly bool, page, limit int) ([]Notification, int64, error) {
+	var notifications []Notification
+	var total int64
+
+	query := db.Model(&Notification{}).Where("recipient = ?", recipient)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Scopes(Paginate(page, limit)).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUnread 用户的未读通知数
+func (n *Notification) CountUnread(db *gorm.DB, recipient string) (int64, error) {
+	var count int64
+	err := db.Model(&Notification{}).Where("recipient = ? AND read_at IS NULL", recipient).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将用户的一条通知标为已读，已读的通知保持原来的已读时间
+func (n *Notification) MarkRead(db *gorm.DB, recipient string, id uint64) error {
+	if err := db.Where("recipient = ?", recipient).First(n, id).Error; err != nil {
+		return err
+	}
+	if n.ReadAt != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := db.Model(n).Update("read_at", now).Error; err != nil {
+		return err
+	}
+	n.ReadAt = &now
+	return nil
+}
+
+// MarkAllRead 将用户的全部未读通知标为已读，返回标记的数量
+func (n *Notification) MarkAllRead(db *gorm.DB, recipient string) (int64, error) {
+	result := db.Model(&Notification{}).Where("recipient = ? AND read_at IS NULL", recipient).Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// excerpt 截取文本的前 size 个字符
+func excerpt(text string, size int) string {
+	if utf8.RuneCountInString(text) <= size {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:size]) + "…"
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"E-Todo/config"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// 提醒的状态
+const (
+	ReminderPending = "pending" // 等待触发
+	ReminderSent    = "sent"    // 已发送
+	ReminderSkipped = "skipped" // 触发时任务已完成或在回收站中，未发送
+	ReminderFailed  = "failed"  // 多次发送失败，不再重试
+)
+
+// Reminder 任务提醒，在 RemindAt 指定的时间，或相对截止时间 OffsetMinutes 分钟时触发
+// 相对截止时间的提醒在任务的截止时间修改后随之调整
+type Reminder struct {
+	ID            uint       `gorm:"primaryKey"`
+	TaskID        uint       `gorm:"not null;index"`
+	RemindAt      *time.Time // 绝对触发时间，相对截止时间的提醒为空
+	OffsetMinutes *int       // 相对截止时间的分钟数，负数表示截止前，绝对时间的提醒为空
+	Note          string     `gorm:"size:500"`
+	FireAt        time.Time  `gorm:"not null;index:idx_reminders_status_fire_at,priority:2"` // 实际触发时间
+	Status        string     `gorm:"size:20;not null;default:'pending';index:idx_reminders_status_fire_at,priority:1"`
+	Attempts      int        `gorm:"not null;default:0"` // 已失败次数
+	ClaimedUntil  *time.Time // 发送中的占用期限，期间其他实例不会发送该提醒；发送失败时为下次重试时间
+	SentChannels  string     `gorm:"size:500"` // 本次触发已发送成功的渠道，逗号分隔，重试时跳过
+	LastError     string     `gorm:"size:1000"`
+	FiredAt       *time.Time // 发送或跳过的时间
+	CreatedBy     string     `gorm:"size:255"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+}
+
+// Create 保存提醒，调用前需设置 FireAt
+func (r *Reminder) Create(tx *gorm.DB) error {
+	r.Status = ReminderPending
+	r.CreatedBy = ChangeContextFrom(tx.Statement.Context).Actor
+	return tx.Create(r).Error
+}
+
+// FetchByTask 按触发时间顺序获取任务的全部提醒
+func (r *Reminder) FetchByTask(db *gorm.DB, taskID uint) ([]Reminder, error) {
+	var reminders []Reminder
+	err := db.Where("task_id = ?", taskID).Order("fire_at, id").Find(&reminders).Error
+	return reminders, err
+}
+
+// FetchByID 查询任务下的提醒
+func (r *Reminder) FetchByID(db *gorm.DB, taskID, id uint) error {
+	return db.Where("task_id = ?", taskID).First(r, id).Error
+}
+
+// Delete 删除提醒
+func (r *Reminder) Delete(tx *gorm.DB) error {
+	return tx.Delete(r).Error
+}
+
+// FetchDue 获取已到触发时间且未被占用的提醒
+func (r *Reminder) FetchDue(db *gorm.DB, now time.Time, limit int) ([]Reminder, error) {
+	var reminders []Reminder
+	err := db.Where("status = ? AND fire_at <= ? AND (claimed_until IS NULL OR claimed_until <= ?)", ReminderPending, now, now).
+		Order("fire_at, id").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+// Claim 占用待发送的提醒直到 until，防止多个实例同时发送，已被其他实例占用或已发送时返回 false
+// until 按秒截断，与数据库的精度一致，之后的更新据此确认提醒仍由自己占用
+func (r *Reminder) Claim(db *gorm.DB, now, until time.Time) (bool, error) {
+	until = until.Truncate(time.Second)
+	result := db.Model(&Reminder{}).
+		Where("id = ? AND status = ? AND fire_at <= ? AND (claimed_until IS NULL OR claimed_until <= ?)", r.ID, ReminderPending, now, now).
+		Update("claimed_until", until)
+	if result.RowsAffected > 0 {
+		r.ClaimedUntil = &until
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+// ChannelSent 判断本次触发是否已发送到渠道
+func (r *Reminder) ChannelSent(name string) bool {
+	for _, sent := range strings.Split(r.SentChannels, ",") {
+		if sent == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkChannelSent 记录已发送到渠道，提醒已不由自己占用（占用超时后被其他实例占用）时返回 false
+func (r *Reminder) MarkChannelSent(db *gorm.DB, name string) (bool, error) {
+	sent := name
+	if r.SentChannels != "" {
+		sent = r.SentChannels + "," + name
+	}
+	ok, err := r.updateClaimed(db, map[string]interface{}{"sent_channels": sent})
+	if ok {
+		r.SentChannels = sent
+	}
+	return ok, err
+}
+
+// Finish 记录提醒已发送或已跳过，提醒已不由自己占用时返回 false
+func (r *Reminder) Finish(db *gorm.DB, status string, now time.Time) (bool, error) {
+	return r.updateClaimed(db, map[string]interface{}{
+		"status":        status,
+		"fired_at":      now,
+		"claimed_until": nil,
+	})
+}
+
+// MarkFailed 记录发送失败，retryAt 之后重试；final 为 true 时不再重试；提醒已不由自己占用时返回 false
+func (r *Reminder) MarkFailed(db *gorm.DB, msg string, retryAt time.Time, final bool) (bool, error) {
+	updates := map[string]interface{}{
+		"attempts":      gorm.Expr("attempts + 1"),
+		"last_error":    msg,
+		"claimed_until": retryAt,
+	}
+	if final {
+		updates["status"] = ReminderFailed
+		updates["fired_at"] = time.Now()
+	}
+	return r.updateClaimed(db, updates)
+}
+
+// updateClaimed 在提醒仍由自己占用时更新提醒
+func (r *Reminder) updateClaimed(db *gorm.DB, updates map[string]interface{}) (bool, error) {
+	if r.ClaimedUntil == nil {
+		return false, nil
+	}
+	result := db.Model(&Reminder{}).
+		Where("id = ? AND status = ? AND claimed_until = ?", r.ID, ReminderPending, *r.ClaimedUntil).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// recordTaskReminders 为新建的截止时间未过的任务添加截止时提醒（DUE_DATE_REMINDERS），
+// 并在截止时间修改后调整相对截止时间的提醒；调整后的触发时间在未来时，已触发的提醒重新等待触发
+func recordTaskReminders(tx *gorm.DB, changes []taskChange) error {
+	now := time.Now()
+	for _, change := range changes {
+		if change.After == nil {
+			continue
+		}
+		task := change.After
+
+		if change.Before == nil {
+			if !config.DueDateReminders() || !task.DueDate.After(now) {
+				continue
+			}
+			offset := 0
+			reminder := Reminder{TaskID: task.ID, OffsetMinutes: &offset, FireAt: task.DueDate}
+			if err := reminder.Create(tx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if change.Before.DueDate.Equal(task.DueDate) {
+			continue
+		}
+		var reminders []Reminder
+		if err := tx.Where("task_id = ? AND offset_minutes IS NOT NULL", task.ID).Find(&reminders).Error; err != nil {
+			return err
+		}
+		for _, reminder := range reminders {
+			fireAt := task.DueDate.Add(time.Duration(*reminder.OffsetMinutes) * time.Minute)
+			updates := map[string]interface{}{"fire_at": fireAt}
+			if fireAt.After(now) {
+				updates["status"] = ReminderPending
+				updates["attempts"] = 0
+				updates["fired_at"] = nil
+				updates["claimed_until"] = nil
+				updates["sent_channels"] = ""
+			}
+			if err := tx.Model(&reminder).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteTaskReminders 删除任务的全部提醒，在硬删除任务时调用
+func deleteTaskReminders(tx *gorm.DB, taskIDs []uint) error {
+	return tx.Where("task_id IN ?", taskIDs).Delete(&Reminder{}).Error
+}
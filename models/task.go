@@ -5,26 +5,92 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
 // Task 任务模型
 type Task struct {
-	ID          uint   `gorm:"primaryKey"`
-	Title       string `gorm:"size:255;not null"`
-	Description string
-	Category    string `gorm:"size:100"`
-	Color       string `gorm:"size:20"`
-	DueDate     time.Time
-	Status      string         `gorm:"type:enum('pending','completed');default:'pending'"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID               uint   `gorm:"primaryKey"`
+	Title            string `gorm:"size:255;not null"`
+	Description      string
+	Category         string `gorm:"size:100"`
+	Color            string `gorm:"size:20"`
+	DueDate          time.Time
+	Status           string         `gorm:"type:enum('pending','completed');default:'pending'"`
+	Version          uint           `gorm:"not null;default:1"` // 乐观锁版本号，每次修改加 1
+	CommentCount     int            `gorm:"not null;default:0"` // 评论数，增删评论时维护，不修改版本号
+	ChecklistTotal   int            `gorm:"not null;default:0"` // 检查项总数，增删检查项时维护，不修改版本号
+	ChecklistChecked int            `gorm:"not null;default:0"` // 已勾选的检查项数
+	CreatedAt        time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+// ErrTaskAlreadyCompleted 任务已完成
+var ErrTaskAlreadyCompleted = errors.New("task already completed")
+
+// ErrTaskNotDeleted 任务不在回收站中，无需恢复
+var ErrTaskNotDeleted = errors.New("task is not in the trash")
+
+// ErrVersionConflict 任务版本冲突，客户端持有的版本已过期
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError 版本冲突错误，携带服务端当前的任务数据以便客户端合并
+type VersionConflictError struct {
+	Current Task
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: task %d is at version %d", e.Current.ID, e.Current.Version)
+}
+
+// Is 使 errors.Is(err, ErrVersionConflict) 成立
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// checkVersion 校验期望版本与当前版本是否一致，expected 为 0 表示不校验
+func (t *Task) checkVersion(expected uint) error {
+	if expected != 0 && expected != t.Version {
+		return &VersionConflictError{Current: *t}
+	}
+	return nil
+}
+
+// versionChanged 条件更新未命中时重新读取任务，返回携带最新数据的版本冲突错误
+func (t *Task) versionChanged(tx *gorm.DB) error {
+	current := Task{ID: t.ID}
+	if err := current.FindTaskByID(tx); err != nil {
+		return fmt.Errorf("failed to query task: %w", err)
+	}
+	return &VersionConflictError{Current: current}
+}
+
+// updateVersioned 以当前版本为条件更新字段并递增版本号，版本已被修改时返回版本冲突错误
+func (t *Task) updateVersioned(tx *gorm.DB, values map[string]interface{}) error {
+	now := time.Now()
+	values["version"] = gorm.Expr("version + 1")
+	values["updated_at"] = now
+	result := tx.Unscoped().Model(&Task{}).Where("id = ? AND version = ?", t.ID, t.Version).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return t.versionChanged(tx)
+	}
+	t.Version++
+	t.UpdatedAt = now
+	return nil
 }
 
 // Create 保存任务到数据库
-func (t *Task) Create() error {
-	return config.DB.Create(t).Error
+func (t *Task) Create(tx *gorm.DB) error {
+	if err := tx.Create(t).Error; err != nil {
+		return err
+	}
+	return recordTaskChanges(tx, TaskEventCreated, taskChange{After: t})
 }
 
 // TaskQueryParams 查询参数结构体
@@ -36,6 +102,10 @@ type TaskQueryParams struct {
 	Status        string
 	Color         string
 	RemainingDays int
+	Sort          string    // 排序字段，前缀 - 表示倒序，为空时不排序
+	AsOf          time.Time // 查询任务在该时刻的状态，零值表示当前状态
+	Assignee      string    // 负责人
+	Watcher       string    // 关注者
 }
 
 // FetchAll 获取所有任务
@@ -44,65 +114,145 @@ func (t *Task) FetchAll(params TaskQueryParams) ([]Task, int64, error) {
 	var total int64
 
 	query := config.DB.Model(&t)
-
-	// 动态查询条件
-	if params.KeyWords != "" {
-		query = query.Where("title LIKE ?", "%"+params.KeyWords+"%")
-	}
-	if params.Category != "" {
-		query = query.Where("category = ?", params.Category)
-	}
-	if params.Status != "" {
-		query = query.Where("status = ?", params.Status)
-	}
-	if params.Color != "" {
-		query = query.Where("color = ?", params.Color)
-	}
-	if params.RemainingDays >= 0 {
-		targetDate := time.Now().AddDate(0, 0, params.RemainingDays)
-		query = query.Where("due_date <= ?", targetDate)
+	if !params.AsOf.IsZero() {
+		// 以该时刻的任务版本代替 tasks 表，包括之后被删除的任务
+		query = query.Table("(?) AS tasks", taskVersionsAsOf(config.DB, params.AsOf))
 	}
+	query = query.Scopes(TaskFilter(params))
 
 	// 分页
-	query.Scopes(Paginate(params.Page, params.Limit)).Find(&tasks).Count(&total)
+	query.Scopes(TaskSort(params.Sort), Paginate(params.Page, params.Limit)).Find(&tasks).Count(&total)
 
 	return tasks, total, query.Error
 }
 
-// Update 更新任务
-func (t *Task) Update() error {
-	return config.DB.Model(&t).Updates(map[string]interface{}{
+// TaskFilter 根据查询参数构造动态查询条件，RemainingDays < 0 表示不按剩余天数过滤
+// 指定 AsOf 时剩余天数从该时刻算起
+func TaskFilter(params TaskQueryParams) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if params.KeyWords != "" {
+			db = db.Where("title LIKE ?", "%"+params.KeyWords+"%")
+		}
+		if params.Category != "" {
+			db = db.Where("category = ?", params.Category)
+		}
+		if params.Status != "" {
+			db = db.Where("status = ?", params.Status)
+		}
+		if params.Color != "" {
+			db = db.Where("color = ?", params.Color)
+		}
+		if params.Assignee != "" {
+			db = taskMemberScope(db, TaskMemberAssignee, params.Assignee)
+		}
+		if params.Watcher != "" {
+			db = taskMemberScope(db, TaskMemberWatcher, params.Watcher)
+		}
+		if params.RemainingDays >= 0 {
+			now := time.Now()
+			if !params.AsOf.IsZero() {
+				now = params.AsOf
+			}
+			targetDate := now.AddDate(0, 0, params.RemainingDays)
+			db = db.Where("due_date <= ?", targetDate)
+		}
+		return db
+	}
+}
+
+// FindMatching 查询符合条件的任务（不分页、不加锁），最多 limit 条
+func (t *Task) FindMatching(db *gorm.DB, params TaskQueryParams, limit int) ([]Task, error) {
+	var tasks []Task
+	err := db.Scopes(TaskFilter(params)).Order("id").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+// FindForUpdate 查询并锁定符合条件的任务（不分页），最多 limit 条，需在事务中调用
+func (t *Task) FindForUpdate(tx *gorm.DB, params TaskQueryParams, limit int) ([]Task, error) {
+	return t.FindMatching(tx.Clauses(clause.Locking{Strength: "UPDATE"}), params, limit)
+}
+
+// Update 更新任务，以任务当前的 Version 作为乐观锁条件
+func (t *Task) Update(tx *gorm.DB) error {
+	// 读取更新前的任务用于记录变更历史
+	before := Task{ID: t.ID}
+	if err := before.FindTaskByID(tx); err != nil {
+		return fmt.Errorf("failed to query task: %w", err)
+	}
+	if before.Status == TaskStatusPending && t.Status == TaskStatusCompleted && before.checklistIncomplete() {
+		return fmt.Errorf("task %d: %w", t.ID, ErrChecklistIncomplete)
+	}
+
+	err := t.updateVersioned(tx, map[string]interface{}{
 		"title":       t.Title,
 		"description": t.Description,
 		"category":    t.Category,
 		"color":       t.Color,
 		"due_date":    t.DueDate,
 		"status":      t.Status,
-	}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return recordTaskChanges(tx, TaskEventUpdated, taskChange{Before: &before, After: t})
 }
 
-// Delete 删除任务
-func (t *Task) Delete() error {
+// Delete 删除任务，调用前设置 Version 可校验期望版本
+func (t *Task) Delete(tx *gorm.DB) error {
+	expected := t.Version
+
 	// 检查任务是否存在
-	if err := t.FindTaskByID(); err != nil {
+	if err := t.FindTaskByID(tx); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("task not found: %w", err)
 		}
 		return fmt.Errorf("failed to query task: %w", err)
 	}
-	return config.DB.Unscoped().Delete(&t).Error
+	if err := t.checkVersion(expected); err != nil {
+		return err
+	}
+
+	result := tx.Unscoped().Where("version = ?", t.Version).Delete(&t)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return t.versionChanged(tx)
+	}
+	// 先记录变更，事件的可见范围取自删除前的成员
+	if err := recordTaskChanges(tx, TaskEventDeleted, taskChange{Before: t}); err != nil {
+		return err
+	}
+	return deleteTaskDependents(tx, []uint{t.ID})
 }
 
-// SoftDelete 软删除任务
-func (t *Task) SoftDelete() error {
+// SoftDelete 软删除任务，调用前设置 Version 可校验期望版本
+func (t *Task) SoftDelete(tx *gorm.DB) error {
+	expected := t.Version
+
 	// 检查任务是否存在
-	if err := t.FindTaskByID(); err != nil {
+	if err := t.FindTaskByID(tx); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("task not found: %w", err)
 		}
 		return fmt.Errorf("failed to query task: %w", err)
 	}
-	return config.DB.Delete(&t).Error
+	if err := t.checkVersion(expected); err != nil {
+		return err
+	}
+
+	// 已在回收站中，无需重复删除
+	if t.DeletedAt.Valid {
+		return nil
+	}
+
+	before := *t
+	now := time.Now()
+	if err := t.updateVersioned(tx, map[string]interface{}{"deleted_at": now}); err != nil {
+		return err
+	}
+	t.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	return recordTaskChanges(tx, TaskEventSoftDeleted, taskChange{Before: &before, After: t})
 }
 
 // Paginate 分页
@@ -119,57 +269,320 @@ func Paginate(page, limit int) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// TaskSortFields 支持排序的字段
+var TaskSortFields = []string{"id", "title", "due_date", "status", "created_at", "updated_at"}
+
+// TaskSort 按字段排序，sort 为字段名，前缀 - 表示倒序，不支持的字段忽略
+func TaskSort(sort string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		column, desc := strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+		for _, field := range TaskSortFields {
+			if field == column {
+				return db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc}).Order("id")
+			}
+		}
+		return db
+	}
+}
+
 // FindTaskByID 根据 ID 查询任务
-func (t *Task) FindTaskByID() error {
-	return config.DB.Unscoped().First(&t, t.ID).Error
+func (t *Task) FindTaskByID(tx *gorm.DB) error {
+	return tx.Unscoped().First(&t, t.ID).Error
 }
 
-// Restore 恢复软删除的任务
-func (t *Task) Restore() error {
-	// 确保只查询软删除的记录
-	if err := config.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", t.ID).First(&t).Error; err != nil {
-		return fmt.Errorf("restore failed: task not found or not soft-deleted: %w", err)
+// Restore 恢复软删除的任务，调用前设置 Version 可校验期望版本
+func (t *Task) Restore(tx *gorm.DB) error {
+	expected := t.Version
+
+	// 任务不存在时为 not found，未被软删除时为冲突
+	if err := t.FindTaskByID(tx); err != nil {
+		return fmt.Errorf("restore failed: task not found: %w", err)
+	}
+	if !t.DeletedAt.Valid {
+		return fmt.Errorf("restore failed: %w: %d", ErrTaskNotDeleted, t.ID)
+	}
+	if err := t.checkVersion(expected); err != nil {
+		return err
 	}
 
 	// 恢复软删除的记录
-	if err := config.DB.Unscoped().Model(&t).UpdateColumn("deleted_at", nil).Error; err != nil {
+	before := *t
+	if err := t.updateVersioned(tx, map[string]interface{}{"deleted_at": nil}); err != nil {
 		return fmt.Errorf("restore failed: unable to update deleted_at: %w", err)
 	}
+	t.DeletedAt = gorm.DeletedAt{}
 
-	return nil
+	return recordTaskChanges(tx, TaskEventRestored, taskChange{Before: &before, After: t})
 }
 
-// Complete 完成任务
-func (t *Task) Complete() error {
-	// 确保只查询未完成的任务
-	if err := config.DB.Where("id = ? AND status = ?", t.ID, TaskStatusPending).First(&t).Error; err != nil {
-		return fmt.Errorf("complete failed: task not found or already completed: %w", err)
+// Complete 完成任务，调用前设置 Version 可校验期望版本
+func (t *Task) Complete(tx *gorm.DB) error {
+	expected := t.Version
+
+	// 锁定任务行，检查项的增删和勾选同样先锁定任务，保证完成条件读到的检查项计数不会并发变化
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", t.ID).First(&t).Error; err != nil {
+		return fmt.Errorf("complete failed: task not found: %w", err)
+	}
+	if err := t.checkVersion(expected); err != nil {
+		return err
+	}
+
+	// 确保只完成未完成的任务
+	if t.Status != TaskStatusPending {
+		return fmt.Errorf("complete failed: %w", ErrTaskAlreadyCompleted)
+	}
+	if t.checklistIncomplete() {
+		return fmt.Errorf("complete failed: %w", ErrChecklistIncomplete)
 	}
 
 	// 完成任务
-	if err := config.DB.Model(&t).Update("status", TaskStatusCompleted).Error; err != nil {
+	before := *t
+	if err := t.updateVersioned(tx, map[string]interface{}{"status": TaskStatusCompleted}); err != nil {
 		return fmt.Errorf("complete failed: unable to update status: %w", err)
 	}
+	t.Status = TaskStatusCompleted
 
-	return nil
+	return recordTaskChanges(tx, TaskEventCompleted, taskChange{Before: &before, After: t})
+}
+
+// ErrBatchAborted 事务模式下存在无法处理的任务，整批操作已回滚
+var ErrBatchAborted = errors.New("batch aborted: not all tasks could be processed")
+
+// BatchItemResult 批量操作中单个任务的处理结果
+type BatchItemResult struct {
+	ID     uint
+	Result string
+}
+
+// batchApply 在事务中执行批量操作：先锁定并逐个检查任务状态，再对可处理的任务执行 apply 并记录 eventType 事件
+// check 返回 BatchResultOK 表示该任务可以处理；atomic 为 true 时任一任务无法处理则整批回滚
+func batchApply(db *gorm.DB, ids []uint, atomic bool, eventType string, check func(task Task) string, apply func(tx *gorm.DB, ids []uint) *gorm.DB) ([]BatchItemResult, int64, error) {
+	ids = uniqueIDs(ids)
+	results := make([]BatchItemResult, 0, len(ids))
+	var affected int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var tasks []Task
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+			return err
+		}
+		found := make(map[uint]Task, len(tasks))
+		for _, task := range tasks {
+			found[task.ID] = task
+		}
+
+		var okIDs []uint
+		for _, id := range ids {
+			result := BatchResultNotFound
+			if task, ok := found[id]; ok {
+				result = check(task)
+			}
+			if result == BatchResultOK {
+				okIDs = append(okIDs, id)
+			}
+			results = append(results, BatchItemResult{ID: id, Result: result})
+		}
+
+		if atomic && len(okIDs) != len(ids) {
+			return ErrBatchAborted
+		}
+		if len(okIDs) == 0 {
+			return nil
+		}
+
+		result := apply(tx, okIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+
+		// 硬删除没有变更后的任务，其他操作重新读取变更后的任务
+		updated := map[uint]Task{}
+		if eventType != TaskEventDeleted {
+			var tasks []Task
+			if err := tx.Unscoped().Where("id IN ?", okIDs).Find(&tasks).Error; err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				updated[task.ID] = task
+			}
+		}
+
+		changes := make([]taskChange, 0, len(okIDs))
+		for _, id := range okIDs {
+			before := found[id]
+			change := taskChange{Before: &before}
+			if after, ok := updated[id]; ok {
+				change.After = &after
+			}
+			changes = append(changes, change)
+		}
+		if err := recordTaskChanges(tx, eventType, changes...); err != nil {
+			return err
+		}
+		// 硬删除在记录变更后删除关联数据，事件的可见范围取自删除前的成员
+		if eventType == TaskEventDeleted {
+			return deleteTaskDependents(tx, okIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return results, 0, err
+	}
+
+	return results, affected, nil
+}
+
+// deleteTaskDependents 删除硬删除的任务的关联数据，软删除时保留以便恢复
+func deleteTaskDependents(tx *gorm.DB, ids []uint) error {
+	if err := deleteTaskComments(tx, ids); err != nil {
+		return err
+	}
+	if err := deleteTaskChecklist(tx, ids); err != nil {
+		return err
+	}
+	if err := deleteTaskMembers(tx, ids); err != nil {
+		return err
+	}
+	if err := deleteTaskReminders(tx, ids); err != nil {
+		return err
+	}
+	return deleteTaskAttachments(tx, ids)
+}
+
+// uniqueIDs 去除重复的 ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]struct{}, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
 }
 
 // BatchDelete 批量硬删除任务
-func (t *Task) BatchDelete(ids []uint) error {
-	return config.DB.Unscoped().Where("id IN ?", ids).Delete(&t).Error
+func (t *Task) BatchDelete(db *gorm.DB, ids []uint, atomic bool) ([]BatchItemResult, int64, error) {
+	return batchApply(db, ids, atomic, TaskEventDeleted, func(task Task) string {
+		return BatchResultOK
+	}, func(tx *gorm.DB, ids []uint) *gorm.DB {
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&Task{})
+	})
 }
 
 // BatchComplete 批量完成任务
-func (t *Task) BatchComplete(ids []uint) error {
-	return config.DB.Model(&t).Where("id IN ? AND status = ?", ids, TaskStatusPending).Update("status", TaskStatusCompleted).Error
+func (t *Task) BatchComplete(db *gorm.DB, ids []uint, atomic bool) ([]BatchItemResult, int64, error) {
+	return batchApply(db, ids, atomic, TaskEventCompleted, func(task Task) string {
+		if task.DeletedAt.Valid {
+			return BatchResultForbidden
+		}
+		if task.Status == TaskStatusCompleted {
+			return BatchResultAlreadyCompleted
+		}
+		if task.checklistIncomplete() {
+			return BatchResultChecklistIncomplete
+		}
+		return BatchResultOK
+	}, func(tx *gorm.DB, ids []uint) *gorm.DB {
+		return tx.Model(&Task{}).Where("id IN ? AND status = ?", ids, TaskStatusPending).Updates(map[string]interface{}{
+			"status":  TaskStatusCompleted,
+			"version": gorm.Expr("version + 1"),
+		})
+	})
 }
 
 // BatchSoftDelete 批量软删除任务
-func (t *Task) BatchSoftDelete(ids []uint) error {
-	return config.DB.Where("id IN ? AND deleted_at IS NULL", ids).Delete(&t).Error
+func (t *Task) BatchSoftDelete(db *gorm.DB, ids []uint, atomic bool) ([]BatchItemResult, int64, error) {
+	return batchApply(db, ids, atomic, TaskEventSoftDeleted, func(task Task) string {
+		if task.DeletedAt.Valid {
+			return BatchResultAlreadyDeleted
+		}
+		return BatchResultOK
+	}, func(tx *gorm.DB, ids []uint) *gorm.DB {
+		return tx.Model(&Task{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+		})
+	})
 }
 
 // BatchRestore 批量恢复任务
-func (t *Task) BatchRestore(ids []uint) error {
-	return config.DB.Unscoped().Model(&t).Where("id IN ? AND deleted_at IS NOT NULL", ids).Update("deleted_at", nil).Error
+func (t *Task) BatchRestore(db *gorm.DB, ids []uint, atomic bool) ([]BatchItemResult, int64, error) {
+	return batchApply(db, ids, atomic, TaskEventRestored, func(task Task) string {
+		if !task.DeletedAt.Valid {
+			return BatchResultNotDeleted
+		}
+		return BatchResultOK
+	}, func(tx *gorm.DB, ids []uint) *gorm.DB {
+		return tx.Unscoped().Model(&Task{}).Where("id IN ? AND deleted_at IS NOT NULL", ids).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	})
+}
+
+// FetchTrash 获取回收站中（已软删除）的任务，按删除时间倒序
+func (t *Task) FetchTrash(page, limit int) ([]Task, int64, error) {
+	var tasks []Task
+	var total int64
+
+	query := config.DB.Unscoped().Model(&Task{}).Where("deleted_at IS NOT NULL").Session(&gorm.Session{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("deleted_at DESC").Scopes(Paginate(page, limit)).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+// EmptyTrash 清空回收站，彻底删除所有软删除的任务
+func (t *Task) EmptyTrash(db *gorm.DB) (int64, error) {
+	return purgeDeleted(db, func(db *gorm.DB) *gorm.DB {
+		return db.Where("deleted_at IS NOT NULL")
+	})
+}
+
+// PurgeDeletedBefore 彻底删除在指定时间之前被软删除的任务
+func (t *Task) PurgeDeletedBefore(db *gorm.DB, before time.Time) (int64, error) {
+	return purgeDeleted(db, func(db *gorm.DB) *gorm.DB {
+		return db.Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+	})
+}
+
+// purgeDeleted 在事务中彻底删除符合条件的任务，并为每个任务记录删除事件
+func purgeDeleted(db *gorm.DB, scope func(db *gorm.DB) *gorm.DB) (int64, error) {
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var tasks []Task
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(tasks))
+		changes := make([]taskChange, 0, len(tasks))
+		for i := range tasks {
+			ids = append(ids, tasks[i].ID)
+			changes = append(changes, taskChange{Before: &tasks[i]})
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&Task{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		// 先记录变更，事件的可见范围取自删除前的成员
+		if err := recordTaskChanges(tx, TaskEventDeleted, changes...); err != nil {
+			return err
+		}
+		return deleteTaskDependents(tx, ids)
+	})
+	return affected, err
 }
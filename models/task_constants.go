@@ -15,3 +15,43 @@ const (
 	ColorPurple = "#800080" // 颜色：紫色
 	ColorBlack  = "#000000" // 颜色：黑色
 )
+
+// 批量操作中单个任务的处理结果
+const (
+	BatchResultOK                  = "ok"                   // 处理成功
+	BatchResultNotFound            = "not_found"            // 任务不存在
+	BatchResultAlreadyCompleted    = "already_completed"    // 任务已完成
+	BatchResultAlreadyDeleted      = "already_deleted"      // 任务已在回收站中
+	BatchResultNotDeleted          = "not_deleted"          // 任务未被软删除，无需恢复
+	BatchResultForbidden           = "forbidden"            // 任务当前状态不允许该操作（如完成回收站中的任务）
+	BatchResultModified            = "modified"             // 任务在操作之后已被修改，无法撤销
+	BatchResultChecklistIncomplete = "checklist_incomplete" // 任务仍有未勾选的检查项，不能完成
+)
+
+// 批量混合操作类型
+const (
+	BatchOpCreate       = "create"        // 创建任务
+	BatchOpUpdate       = "update"        // 更新任务字段
+	BatchOpComplete     = "complete"      // 完成任务
+	BatchOpSoftDelete   = "soft_delete"   // 软删除任务
+	BatchOpRestore      = "restore"       // 恢复任务
+	BatchOpDelete       = "delete"        // 硬删除任务
+	BatchOpMoveCategory = "move_category" // 移动任务到其他分类
+)
+
+// 批量混合操作中单个操作的执行状态
+const (
+	BatchOpStatusOK      = "ok"      // 执行成功
+	BatchOpStatusError   = "error"   // 执行失败，整批回滚
+	BatchOpStatusSkipped = "skipped" // 前序操作失败，未执行
+)
+
+// 任务变更事件类型
+const (
+	TaskEventCreated     = "task.created"      // 创建任务
+	TaskEventUpdated     = "task.updated"      // 更新任务字段
+	TaskEventCompleted   = "task.completed"    // 完成任务
+	TaskEventSoftDeleted = "task.soft_deleted" // 软删除任务
+	TaskEventRestored    = "task.restored"     // 恢复任务
+	TaskEventDeleted     = "task.deleted"      // 硬删除任务
+)
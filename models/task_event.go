@@ -0,0 +1,184 @@
+package models
+
+import (
+	"E-Todo/config"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TaskEvent 任务变更事件日志，与任务变更在同一事务中写入，自增 ID 即事件 ID
+// 同时作为发件箱（outbox）：PublishedAt 为空的事件尚未发布到各事件接收方
+type TaskEvent struct {
+	ID           uint64     `gorm:"primaryKey"`
+	Type         string     `gorm:"size:50;not null"`
+	TaskID       uint       `gorm:"not null;index"`
+	Payload      string     `gorm:"type:text;not null"` // 变更后的任务（硬删除为删除前的任务），JSON
+	Audience     string     `gorm:"type:text"`          // 可见该事件的用户，JSON 数组；为空表示所有人可见
+	PublishedAt  *time.Time `gorm:"index"`              // 发布时间，为空表示待发布
+	ClaimedUntil *time.Time // 发布中的占用期限，期间其他实例不会发布该事件；发布失败时为下次重试时间
+	Attempts     int        `gorm:"not null;default:0"` // 发布失败次数
+	LastError    string     `gorm:"size:1000"`          // 最近一次发布失败的错误
+	CreatedAt    time.Time  `gorm:"autoCreateTime;index"`
+}
+
+// Task 解析事件中的任务数据
+func (e *TaskEvent) Task() (Task, error) {
+	var task Task
+	err := json.Unmarshal([]byte(e.Payload), &task)
+	return task, err
+}
+
+// Viewers 解析可见该事件的用户，为空表示所有人可见
+func (e *TaskEvent) Viewers() ([]string, error) {
+	if e.Audience == "" {
+		return nil, nil
+	}
+	var viewers []string
+	err := json.Unmarshal([]byte(e.Audience), &viewers)
+	return viewers, err
+}
+
+// recordTaskEvents 在当前事务中记录任务变更事件，并记录变更时任务的可见范围
+// 需在成员变更和变更历史写入之后、硬删除关联数据之前调用
+func recordTaskEvents(tx *gorm.DB, eventType string, tasks ...Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	audiences, err := taskAudiences(tx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to find task audience: %w", err)
+	}
+
+	events := make([]TaskEvent, 0, len(tasks))
+	for _, task := range tasks {
+		payload, err := json.Marshal(task)
+		if err != nil {
+			return fmt.Errorf("failed to encode task event: %w", err)
+		}
+		event := TaskEvent{Type: eventType, TaskID: task.ID, Payload: string(payload)}
+		if viewers := audiences[task.ID]; len(viewers) > 0 {
+			audience, err := json.Marshal(viewers)
+			if err != nil {
+				return fmt.Errorf("failed to encode task event: %w", err)
+			}
+			event.Audience = string(audience)
+		}
+		events = append(events, event)
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to record task event: %w", err)
+	}
+	return nil
+}
+
+// taskAudiences 查询任务的可见范围：没有负责人和关注者的任务所有人可见（不在结果中），
+// 否则仅负责人、关注者和任务的创建者可见
+func taskAudiences(tx *gorm.DB, ids []uint) (map[uint][]string, error) {
+	var members []TaskMember
+	if err := tx.Where("task_id IN ?", ids).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	var creators []TaskHistory
+	if err := tx.Select("task_id", "actor").Where("task_id IN ? AND action = ?", ids, TaskEventCreated).
+		Order("id").Find(&creators).Error; err != nil {
+		return nil, err
+	}
+
+	audiences := map[uint][]string{}
+	seen := map[uint]map[string]bool{}
+	add := func(taskID uint, user string) {
+		if seen[taskID] == nil {
+			seen[taskID] = map[string]bool{}
+		}
+		if user != "" && !seen[taskID][user] {
+			seen[taskID][user] = true
+			audiences[taskID] = append(audiences[taskID], user)
+		}
+	}
+	for _, member := range members {
+		add(member.TaskID, member.User)
+	}
+	for _, creator := range creators {
+		if _, ok := audiences[creator.TaskID]; ok {
+			add(creator.TaskID, creator.Actor)
+		}
+	}
+	return audiences, nil
+}
+
+// TaskAudience 查询单个任务当前的可见范围，为空表示所有人可见
+func TaskAudience(db *gorm.DB, taskID uint) ([]string, error) {
+	audiences, err := taskAudiences(db, []uint{taskID})
+	return audiences[taskID], err
+}
+
+// FetchSince 按 ID 顺序获取指定事件之后的事件，最多 limit 条
+func (e *TaskEvent) FetchSince(afterID uint64, limit int) ([]TaskEvent, error) {
+	var events []TaskEvent
+	err := config.DB.Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// FetchByIDs 按 ID 顺序获取指定的事件，不存在的事件忽略
+func (e *TaskEvent) FetchByIDs(ids []uint64) ([]TaskEvent, error) {
+	var events []TaskEvent
+	err := config.DB.Where("id IN ?", ids).Order("id").Find(&events).Error
+	return events, err
+}
+
+// LatestID 获取最新事件的 ID，没有事件时返回 0
+func (e *TaskEvent) LatestID() (uint64, error) {
+	var id uint64
+	err := config.DB.Model(&TaskEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// FetchUnpublished 按 ID 顺序获取待发布且未被占用的事件，最多 limit 条
+func (e *TaskEvent) FetchUnpublished(now time.Time, limit int) ([]TaskEvent, error) {
+	var events []TaskEvent
+	err := config.DB.Where("published_at IS NULL AND (claimed_until IS NULL OR claimed_until <= ?)", now).
+		Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// Claim 占用待发布的事件直到 until，防止多个实例同时发布，已被其他实例占用或已发布时返回 false
+func (e *TaskEvent) Claim(now, until time.Time) (bool, error) {
+	result := config.DB.Model(&TaskEvent{}).
+		Where("id = ? AND published_at IS NULL AND (claimed_until IS NULL OR claimed_until <= ?)", e.ID, now).
+		Update("claimed_until", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkPublished 标记事件已发布
+func (e *TaskEvent) MarkPublished(now time.Time) error {
+	return config.DB.Model(e).Updates(map[string]interface{}{
+		"published_at":  now,
+		"claimed_until": nil,
+		"last_error":    "",
+	}).Error
+}
+
+// MarkFailed 记录发布失败，retryAt 之后重试
+func (e *TaskEvent) MarkFailed(errMsg string, retryAt time.Time) error {
+	return config.DB.Model(e).Updates(map[string]interface{}{
+		"attempts":      gorm.Expr("attempts + 1"),
+		"last_error":    errMsg,
+		"claimed_until": retryAt,
+	}).Error
+}
+
+// PurgeBefore 删除指定时间之前已发布的事件，未发布的事件保留到发布成功
+func (e *TaskEvent) PurgeBefore(before time.Time) (int64, error) {
+	result := config.DB.Where("published_at IS NOT NULL AND created_at < ?", before).Delete(&TaskEvent{})
+	return result.RowsAffected, result.Error
+}
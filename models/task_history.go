@@ -0,0 +1,173 @@
+package models
+
+import (
+	"E-Todo/config"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ChangeContext 发起变更的调用方信息，随 context 传入事务，记录到任务变更历史中
+type ChangeContext struct {
+	Actor     string // 调用方标识，为空表示匿名调用方
+	RequestID string // 请求 ID
+}
+
+type changeContextKey struct{}
+
+// WithChangeContext 返回携带调用方信息的 context
+func WithChangeContext(ctx context.Context, cc ChangeContext) context.Context {
+	return context.WithValue(ctx, changeContextKey{}, cc)
+}
+
+// ChangeContextFrom 读取 context 中的调用方信息，未设置时返回零值
+func ChangeContextFrom(ctx context.Context) ChangeContext {
+	if ctx == nil {
+		return ChangeContext{}
+	}
+	cc, _ := ctx.Value(changeContextKey{}).(ChangeContext)
+	return cc
+}
+
+// TaskHistory 任务变更历史，每个变更的字段一条记录
+type TaskHistory struct {
+	ID          uint64    `gorm:"primaryKey"`
+	TaskID      uint      `gorm:"not null;index"`
+	OperationID uint64    `gorm:"index"`            // 所属的操作，可通过该操作撤销变更
+	Action      string    `gorm:"size:50;not null"` // 变更类型，与事件类型一致，如 task.updated
+	Field       string    `gorm:"size:50;not null"`
+	OldValue    string    `gorm:"type:text"` // 变更前的值，创建时为空
+	NewValue    string    `gorm:"type:text"` // 变更后的值，硬删除时为空
+	Actor       string    `gorm:"size:255"`
+	RequestID   string    `gorm:"size:64;index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// FetchByTask 按时间顺序分页获取任务的变更历史
+func (h *TaskHistory) FetchByTask(taskID uint, page, limit int) ([]TaskHistory, int64, error) {
+	var history []TaskHistory
+	var total int64
+
+	query := config.DB.Model(&TaskHistory{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id").Scopes(Paginate(page, limit)).Find(&history).Error
+	return history, total, err
+}
+
+// taskChange 一个任务的变更，Before 为变更前的任务（创建时为空），After 为变更后的任务（硬删除时为空）
+type taskChange struct {
+	Before *Task
+	After  *Task
+}
+
+// recordTaskChanges 在当前事务中将任务变更记录为一次操作，并记录变更事件和字段级变更历史
+// 调用方信息取自事务的 context，见 WithChangeContext；在 RecordAsOneOperation 中时合并到该操作
+func recordTaskChanges(tx *gorm.DB, eventType string, changes ...taskChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if batch := taskOperationBatchFrom(tx); batch != nil {
+		operation, err := batch.add(tx, changes)
+		if err != nil {
+			return err
+		}
+		return recordTaskChangeLog(tx, operation.ID, eventType, changes)
+	}
+
+	operation, err := recordTaskOperation(tx, eventType, nil, changes)
+	if err != nil {
+		return err
+	}
+	return recordTaskChangeLog(tx, operation.ID, eventType, changes)
+}
+
+// recordTaskChangeLog 在当前事务中记录属于某个操作的任务变更事件和字段级变更历史
+func recordTaskChangeLog(tx *gorm.DB, operationID uint64, eventType string, changes []taskChange) error {
+	// 事件中为变更后的任务，硬删除为删除前的任务
+	tasks := make([]Task, 0, len(changes))
+	for _, change := range changes {
+		if change.After != nil {
+			tasks = append(tasks, *change.After)
+		} else {
+			tasks = append(tasks, *change.Before)
+		}
+	}
+	if err := recordTaskVersions(tx, changes); err != nil {
+		return err
+	}
+
+	cc := ChangeContextFrom(tx.Statement.Context)
+	var history []TaskHistory
+	for i, change := range changes {
+		var before, after Task
+		if change.Before != nil {
+			before = *change.Before
+		}
+		if change.After != nil {
+			after = *change.After
+		}
+		for _, field := range diffTaskFields(before, after) {
+			field.TaskID = tasks[i].ID
+			field.OperationID = operationID
+			field.Action = eventType
+			field.Actor = cc.Actor
+			field.RequestID = cc.RequestID
+			history = append(history, field)
+		}
+	}
+	if len(history) > 0 {
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+	}
+	if err := recordTaskReminders(tx, changes); err != nil {
+		return err
+	}
+	if err := recordTaskMentions(tx, changes); err != nil {
+		return err
+	}
+	// 事件最后记录，使可见范围包含描述中新提及的关注者
+	return recordTaskEvents(tx, eventType, tasks...)
+}
+
+// diffTaskFields 比较任务变更前后的字段，返回发生变化的字段
+func diffTaskFields(before, after Task) []TaskHistory {
+	var changed []TaskHistory
+	for _, field := range taskHistoryFields {
+		oldValue, newValue := field.value(before), field.value(after)
+		if oldValue != newValue {
+			changed = append(changed, TaskHistory{Field: field.name, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return changed
+}
+
+// taskHistoryFields 记录变更历史的字段，零值格式化为空字符串
+var taskHistoryFields = []struct {
+	name  string
+	value func(t Task) string
+}{
+	{"title", func(t Task) string { return t.Title }},
+	{"description", func(t Task) string { return t.Description }},
+	{"category", func(t Task) string { return t.Category }},
+	{"color", func(t Task) string { return t.Color }},
+	{"due_date", func(t Task) string { return formatHistoryTime(t.DueDate, "2006-01-02T15:04Z") }},
+	{"status", func(t Task) string { return t.Status }},
+	{"deleted_at", func(t Task) string {
+		if !t.DeletedAt.Valid {
+			return ""
+		}
+		return formatHistoryTime(t.DeletedAt.Time, "2006-01-02T15:04:05Z")
+	}},
+}
+
+// formatHistoryTime 格式化时间，零值返回空字符串
+func formatHistoryTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
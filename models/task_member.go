@@ -0,0 +1,138 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 任务成员的角色
+const (
+	TaskMemberAssignee = "assignee" // 负责人
+	TaskMemberWatcher  = "watcher"  // 关注者
+)
+
+// TaskMember 任务的负责人或关注者，同一用户可同时担任两种角色
+type TaskMember struct {
+	ID        uint      `gorm:"primaryKey"`
+	TaskID    uint      `gorm:"not null;uniqueIndex:idx_task_members_task_user_role"`
+	User      string    `gorm:"size:255;not null;uniqueIndex:idx_task_members_task_user_role;index"`
+	Role      string    `gorm:"size:20;not null;uniqueIndex:idx_task_members_task_user_role"`
+	AddedBy   string    `gorm:"size:255"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// FetchByTask 按加入顺序获取任务的全部成员
+func (m *TaskMember) FetchByTask(db *gorm.DB, taskID uint) ([]TaskMember, error) {
+	var members []TaskMember
+	err := db.Where("task_id = ?", taskID).Order("id").Find(&members).Error
+	return members, err
+}
+
+// Add 添加任务成员，返回是否新加入；已是该角色的成员时不做修改
+// 将他人设为负责人时通知对方
+func (m *TaskMember) Add(tx *gorm.DB) (bool, error) {
+	m.AddedBy = ChangeContextFrom(tx.Statement.Context).Actor
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	if m.Role == TaskMemberAssignee && m.User != m.AddedBy {
+		notification := Notification{Recipient: m.User, Type: NotificationAssigned, TaskID: m.TaskID, Actor: m.AddedBy}
+		if err := tx.Create(&notification).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Remove 移除任务成员，返回是否存在该成员
+func (m *TaskMember) Remove(tx *gorm.DB) (bool, error) {
+	result := tx.Where("task_id = ? AND user = ? AND role = ?", m.TaskID, m.User, m.Role).Delete(&TaskMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// taskMemberScope 筛选 user 担任 role 的任务
+func taskMemberScope(db *gorm.DB, role, user string) *gorm.DB {
+	members := db.Session(&gorm.Session{NewDB: true}).Model(&TaskMember{}).
+		Select("task_id").Where("role = ? AND user = ?", role, user)
+	return db.Where("id IN (?)", members)
+}
+
+// deleteTaskMembers 删除任务的全部成员，在硬删除任务时调用
+func deleteTaskMembers(tx *gorm.DB, taskIDs []uint) error {
+	return tx.Where("task_id IN ?", taskIDs).Delete(&TaskMember{}).Error
+}
+
+// mentionPattern 匹配 @用户名，@ 前不能是字母、数字或 .，以免匹配到邮箱地址
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([A-Za-z0-9][\w.-]{0,254})`)
+
+// parseMentions 按出现顺序返回文本中 @ 提及的用户，不重复
+func parseMentions(text string) []string {
+	var users []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// 句末的标点不属于用户名
+		user := strings.TrimRight(match[1], ".-")
+		if user != "" && !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// recordMentions 处理文本从 before 修改为 after 时新增的 @ 提及：被提及的用户成为任务的关注者并收到通知
+// 在描述或评论中提及自己不会产生通知；commentID 不为空表示在评论中提及
+func recordMentions(tx *gorm.DB, taskID uint, commentID *uint, before, after string) error {
+	previous := map[string]bool{}
+	for _, user := range parseMentions(before) {
+		previous[user] = true
+	}
+	actor := ChangeContextFrom(tx.Statement.Context).Actor
+
+	var notifications []Notification
+	for _, user := range parseMentions(after) {
+		if previous[user] || user == actor {
+			continue
+		}
+		watcher := TaskMember{TaskID: taskID, User: user, Role: TaskMemberWatcher}
+		if _, err := watcher.Add(tx); err != nil {
+			return err
+		}
+		notifications = append(notifications, Notification{
+			Recipient: user,
+			Type:      NotificationMentioned,
+			TaskID:    taskID,
+			CommentID: commentID,
+			Actor:     actor,
+			Excerpt:   excerpt(after, notificationExcerptSize),
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return tx.Create(&notifications).Error
+}
+
+// recordTaskMentions 处理任务描述中新增的 @ 提及，硬删除的任务不处理
+func recordTaskMentions(tx *gorm.DB, changes []taskChange) error {
+	for _, change := range changes {
+		if change.After == nil {
+			continue
+		}
+		var before string
+		if change.Before != nil {
+			before = change.Before.Description
+		}
+		if before == change.After.Description {
+			continue
+		}
+		if err := recordMentions(tx, change.After.ID, nil, before, change.After.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package models
+
+import (
+	"E-Todo/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// TaskActionUndo 撤销操作的操作类型
+const TaskActionUndo = "task.undo"
+
+// TaskActionBatch 混合批量操作（一批中包含多种操作）的操作类型
+const TaskActionBatch = "task.batch"
+
+// ErrOperationAlreadyUndone 操作已被撤销
+var ErrOperationAlreadyUndone = errors.New("operation already undone")
+
+// TaskOperation 一次任务变更操作（单个或批量），保存操作前的任务快照以便撤销
+type TaskOperation struct {
+	ID        uint64              `gorm:"primaryKey"`
+	Action    string              `gorm:"size:50;not null"` // 操作类型，与事件类型一致；撤销操作为 task.undo，混合批量操作为 task.batch
+	TaskCount int                 `gorm:"not null"`
+	Actor     string              `gorm:"size:255;index"`
+	RequestID string              `gorm:"size:64;index"`
+	UndoOf    *uint64             // 撤销操作所撤销的操作 ID
+	UndoneAt  *time.Time          // 被撤销的时间，未撤销时为空
+	CreatedAt time.Time           `gorm:"autoCreateTime;index"`
+	Items     []TaskOperationItem `gorm:"foreignKey:OperationID"`
+}
+
+// TaskOperationItem 操作涉及的单个任务
+type TaskOperationItem struct {
+	ID           uint64 `gorm:"primaryKey"`
+	OperationID  uint64 `gorm:"not null;index"`
+	TaskID       uint   `gorm:"not null"`
+	Snapshot     string `gorm:"type:text"` // 操作前任务的 JSON 快照，创建任务时为空
+	AfterVersion uint   // 操作后的任务版本，硬删除时为 0
+}
+
+// UndoItemResult 撤销操作中单个任务的处理结果，Task 为撤销后（或无法撤销时当前）的任务
+type UndoItemResult struct {
+	TaskID uint
+	Result string // ok / not_found / modified
+	Task   *Task
+}
+
+// FetchAll 按时间倒序分页获取操作，actor、requestID 为空时不过滤
+func (o *TaskOperation) FetchAll(actor, requestID string, page, limit int) ([]TaskOperation, int64, error) {
+	var operations []TaskOperation
+	var total int64
+
+	query := config.DB.Model(&TaskOperation{})
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("Items", selectOperationTasks).Order("id DESC").Scopes(Paginate(page, limit)).Find(&operations).Error
+	return operations, total, err
+}
+
+// FetchByID 根据 ID 查询操作及其涉及的任务 ID
+func (o *TaskOperation) FetchByID(id uint64) error {
+	return config.DB.Preload("Items", selectOperationTasks).First(o, id).Error
+}
+
+// PurgeBefore 删除指定时间之前的操作及其任务快照
+func (o *TaskOperation) PurgeBefore(before time.Time) (int64, error) {
+	var affected int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&TaskOperation{}).Select("id").Where("created_at < ?", before)
+		if err := tx.Where("operation_id IN (?)", ids).Delete(&TaskOperationItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("created_at < ?", before).Delete(&TaskOperation{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
+}
+
+// selectOperationTasks 预加载操作涉及的任务时不读取快照
+func selectOperationTasks(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "operation_id", "task_id").Order("id")
+}
+
+// TaskIDs 操作涉及的任务 ID
+func (o *TaskOperation) TaskIDs() []uint {
+	ids := make([]uint, 0, len(o.Items))
+	for _, item := range o.Items {
+		ids = append(ids, item.TaskID)
+	}
+	return ids
+}
+
+// recordTaskOperation 在当前事务中记录操作及操作前的任务快照
+func recordTaskOperation(tx *gorm.DB, action string, undoOf *uint64, changes []taskChange) (TaskOperation, error) {
+	cc := ChangeContextFrom(tx.Statement.Context)
+	operation := TaskOperation{
+		Action:    action,
+		TaskCount: len(changes),
+		Actor:     cc.Actor,
+		RequestID: cc.RequestID,
+		UndoOf:    undoOf,
+	}
+	if err := tx.Omit("Items").Create(&operation).Error; err != nil {
+		return TaskOperation{}, err
+	}
+
+	items := make([]TaskOperationItem, 0, len(changes))
+	for _, change := range changes {
+		item, err := newTaskOperationItem(operation.ID, change)
+		if err != nil {
+			return TaskOperation{}, err
+		}
+		items = append(items, item)
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		return TaskOperation{}, err
+	}
+	return operation, nil
+}
+
+// newTaskOperationItem 根据任务变更构造操作项
+func newTaskOperationItem(operationID uint64, change taskChange) (TaskOperationItem, error) {
+	item := TaskOperationItem{OperationID: operationID}
+	if change.Before != nil {
+		before, err := json.Marshal(change.Before)
+		if err != nil {
+			return TaskOperationItem{}, err
+		}
+		item.TaskID = change.Before.ID
+		item.Snapshot = string(before)
+	}
+	if change.After != nil {
+		item.TaskID = change.After.ID
+		item.AfterVersion = change.After.Version
+	}
+	return item, nil
+}
+
+// taskOperationBatchKey 事务 context 中正在记录的合并操作的键
+type taskOperationBatchKey struct{}
+
+// taskOperationBatch 将一个事务中的多次任务变更合并记录为一次操作，见 RecordAsOneOperation
+type taskOperationBatch struct {
+	action    string
+	operation *TaskOperation
+	items     map[uint]*TaskOperationItem // 按任务合并的操作项
+	order     []uint                      // 任务首次变更的顺序
+}
+
+// RecordAsOneOperation 在事务中执行 fn，fn 中经由传入的 tx 产生的全部任务变更记录为一次 action 操作，
+// 撤销该操作时一并撤销；同一任务多次变更时保留第一次变更前的快照和最后一次变更后的版本
+func RecordAsOneOperation(tx *gorm.DB, action string, fn func(tx *gorm.DB) error) error {
+	batch := &taskOperationBatch{action: action, items: map[uint]*TaskOperationItem{}}
+	ctx := context.WithValue(tx.Statement.Context, taskOperationBatchKey{}, batch)
+	if err := fn(tx.WithContext(ctx)); err != nil {
+		return err
+	}
+	return batch.flush(tx)
+}
+
+// taskOperationBatchFrom 读取事务 context 中正在记录的合并操作
+func taskOperationBatchFrom(tx *gorm.DB) *taskOperationBatch {
+	batch, _ := tx.Statement.Context.Value(taskOperationBatchKey{}).(*taskOperationBatch)
+	return batch
+}
+
+// add 将变更合并到操作中，首次变更时创建操作
+func (b *taskOperationBatch) add(tx *gorm.DB, changes []taskChange) (TaskOperation, error) {
+	if b.operation == nil {
+		cc := ChangeContextFrom(tx.Statement.Context)
+		operation := TaskOperation{Action: b.action, Actor: cc.Actor, RequestID: cc.RequestID}
+		if err := tx.Omit("Items").Create(&operation).Error; err != nil {
+			return TaskOperation{}, err
+		}
+		b.operation = &operation
+	}
+
+	for _, change := range changes {
+		item, err := newTaskOperationItem(b.operation.ID, change)
+		if err != nil {
+			return TaskOperation{}, err
+		}
+		if existing, ok := b.items[item.TaskID]; ok {
+			existing.AfterVersion = item.AfterVersion
+			continue
+		}
+		b.items[item.TaskID] = &item
+		b.order = append(b.order, item.TaskID)
+	}
+	return *b.operation, nil
+}
+
+// flush 保存合并后的操作项，同一批中创建后又硬删除的任务无需撤销，不记录
+func (b *taskOperationBatch) flush(tx *gorm.DB) error {
+	if b.operation == nil {
+		return nil
+	}
+	items := make([]TaskOperationItem, 0, len(b.order))
+	for _, id := range b.order {
+		item := b.items[id]
+		if item.Snapshot == "" && item.AfterVersion == 0 {
+			continue
+		}
+		items = append(items, *item)
+	}
+	if len(items) > 0 {
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(b.operation).Update("task_count", len(items)).Error
+}
+
+// Undo 撤销操作，将操作涉及的任务恢复为操作前的快照，并作为一次新的操作记录
+// 任务在操作之后被修改或删除时无法撤销，atomic 为 true 时任一任务无法撤销则整体回滚
+// 没有任何任务被撤销时不记录撤销操作，原操作仍可再次撤销
+func (o *TaskOperation) Undo(db *gorm.DB, atomic bool) (TaskOperation, []UndoItemResult, error) {
+	var undo TaskOperation
+	var results []UndoItemResult
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁定操作，避免同时撤销
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(o, o.ID).Error; err != nil {
+			return err
+		}
+		if o.UndoneAt != nil {
+			return fmt.Errorf("%w: %d", ErrOperationAlreadyUndone, o.ID)
+		}
+		var items []TaskOperationItem
+		if err := tx.Where("operation_id = ?", o.ID).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+
+		// 按事件类型分组记录撤销产生的变更
+		var changes []taskChange
+		var eventTypes []string
+		results = make([]UndoItemResult, 0, len(items))
+		for _, item := range items {
+			change, eventType, result, err := undoTaskOperationItem(tx, item)
+			if err != nil {
+				return err
+			}
+			if result.Result == BatchResultOK {
+				changes = append(changes, change)
+				eventTypes = append(eventTypes, eventType)
+			}
+			results = append(results, result)
+		}
+
+		if atomic && len(changes) != len(items) {
+			return ErrBatchAborted
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var err error
+		if undo, err = recordTaskOperation(tx, TaskActionUndo, &o.ID, changes); err != nil {
+			return err
+		}
+		for _, eventType := range uniqueStrings(eventTypes) {
+			var group []taskChange
+			for i, change := range changes {
+				if eventTypes[i] == eventType {
+					group = append(group, change)
+				}
+			}
+			if err = recordTaskChangeLog(tx, undo.ID, eventType, group); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err = tx.Model(o).Update("undone_at", now).Error; err != nil {
+			return err
+		}
+		o.UndoneAt = &now
+		return nil
+	})
+	if err != nil {
+		return TaskOperation{}, results, err
+	}
+	return undo, results, nil
+}
+
+// undoTaskOperationItem 将单个任务恢复为操作前的快照，返回产生的变更及其事件类型
+// 任务的版本与操作后的版本不一致时视为已被修改，不做处理
+func undoTaskOperationItem(tx *gorm.DB, item TaskOperationItem) (taskChange, string, UndoItemResult, error) {
+	result := UndoItemResult{TaskID: item.TaskID}
+
+	var tasks []Task
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", item.TaskID).Find(&tasks).Error; err != nil {
+		return taskChange{}, "", result, err
+	}
+	var current *Task
+	if len(tasks) > 0 {
+		current = &tasks[0]
+		result.Task = current
+	}
+
+	// 操作为硬删除：按快照重新创建任务
+	if item.AfterVersion == 0 {
+		if current != nil {
+			result.Result = BatchResultModified
+			return taskChange{}, "", result, nil
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(item.Snapshot), &task); err != nil {
+			return taskChange{}, "", result, fmt.Errorf("invalid snapshot of task %d: %w", item.TaskID, err)
+		}
+		// 评论、检查项等关联数据已随硬删除清除
+		task.Version++
+		task.CommentCount = 0
+		task.ChecklistTotal, task.ChecklistChecked = 0, 0
+		task.UpdatedAt = time.Now()
+		if err := tx.Create(&task).Error; err != nil {
+			return taskChange{}, "", result, err
+		}
+		result.Result, result.Task = BatchResultOK, &task
+		return taskChange{After: &task}, TaskEventCreated, result, nil
+	}
+
+	if current == nil {
+		result.Result = BatchResultNotFound
+		return taskChange{}, "", result, nil
+	}
+	if current.Version != item.AfterVersion {
+		result.Result = BatchResultModified
+		return taskChange{}, "", result, nil
+	}
+
+	before := *current
+	task := *current
+
+	// 操作为创建：移入回收站
+	if item.Snapshot == "" {
+		now := time.Now()
+		if err := task.updateVersioned(tx, map[string]interface{}{"deleted_at": now}); err != nil {
+			return taskChange{}, "", result, err
+		}
+		task.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		result.Result, result.Task = BatchResultOK, &task
+		return taskChange{Before: &before, After: &task}, TaskEventSoftDeleted, result, nil
+	}
+
+	var snapshot Task
+	if err := json.Unmarshal([]byte(item.Snapshot), &snapshot); err != nil {
+		return taskChange{}, "", result, fmt.Errorf("invalid snapshot of task %d: %w", item.TaskID, err)
+	}
+	var deletedAt interface{}
+	if snapshot.DeletedAt.Valid {
+		deletedAt = snapshot.DeletedAt.Time
+	}
+	err := task.updateVersioned(tx, map[string]interface{}{
+		"title":       snapshot.Title,
+		"description": snapshot.Description,
+		"category":    snapshot.Category,
+		"color":       snapshot.Color,
+		"due_date":    snapshot.DueDate,
+		"status":      snapshot.Status,
+		"deleted_at":  deletedAt,
+	})
+	if err != nil {
+		return taskChange{}, "", result, err
+	}
+	task.Title, task.Description, task.Category, task.Color = snapshot.Title, snapshot.Description, snapshot.Category, snapshot.Color
+	task.DueDate, task.Status, task.DeletedAt = snapshot.DueDate, snapshot.Status, snapshot.DeletedAt
+
+	eventType := TaskEventUpdated
+	switch {
+	case before.DeletedAt.Valid && !task.DeletedAt.Valid:
+		eventType = TaskEventRestored
+	case !before.DeletedAt.Valid && task.DeletedAt.Valid:
+		eventType = TaskEventSoftDeleted
+	}
+	result.Result, result.Task = BatchResultOK, &task
+	return taskChange{Before: &before, After: &task}, eventType, result, nil
+}
+
+// uniqueStrings 去除重复的字符串，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		unique = append(unique, value)
+	}
+	return unique
+}
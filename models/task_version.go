@@ -0,0 +1,79 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// TaskVersion 任务在一段时间内的完整状态，[ValidFrom, ValidTo) 内有效，ValidTo 为空表示当前版本
+// 硬删除的任务保留其最后一个版本，用于查询任务在过去某一时刻的状态
+type TaskVersion struct {
+	ID          uint64 `gorm:"primaryKey"`
+	TaskID      uint   `gorm:"not null;index"`
+	Title       string `gorm:"size:255;not null"`
+	Description string
+	Category    string `gorm:"size:100"`
+	Color       string `gorm:"size:20"`
+	DueDate     time.Time
+	Status      string     `gorm:"size:20"`
+	Version     uint       `gorm:"not null"`
+	CreatedAt   time.Time  // 任务的创建时间
+	UpdatedAt   time.Time  // 任务的更新时间
+	DeletedAt   *time.Time // 任务的软删除时间
+	ValidFrom   time.Time  `gorm:"not null;index"`
+	ValidTo     *time.Time `gorm:"index"`
+}
+
+// recordTaskVersions 在当前事务中结束变更任务的当前版本，并为变更后的任务写入新版本
+func recordTaskVersions(tx *gorm.DB, changes []taskChange) error {
+	now := time.Now()
+	ids := make([]uint, 0, len(changes))
+	var versions []TaskVersion
+	for _, change := range changes {
+		if change.Before != nil {
+			ids = append(ids, change.Before.ID)
+		}
+		if change.After == nil {
+			continue
+		}
+
+		t := change.After
+		version := TaskVersion{
+			TaskID:      t.ID,
+			Title:       t.Title,
+			Description: t.Description,
+			Category:    t.Category,
+			Color:       t.Color,
+			DueDate:     t.DueDate,
+			Status:      t.Status,
+			Version:     t.Version,
+			CreatedAt:   t.CreatedAt,
+			UpdatedAt:   t.UpdatedAt,
+			ValidFrom:   now,
+		}
+		if t.DeletedAt.Valid {
+			deletedAt := t.DeletedAt.Time
+			version.DeletedAt = &deletedAt
+		}
+		versions = append(versions, version)
+	}
+
+	if len(ids) > 0 {
+		// UpdatedAt 为任务的更新时间，使用 UpdateColumn 避免被自动更新
+		err := tx.Model(&TaskVersion{}).Where("task_id IN ? AND valid_to IS NULL", ids).UpdateColumn("valid_to", now).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+	return tx.Create(&versions).Error
+}
+
+// taskVersionsAsOf 查询 asOf 时刻各任务的版本，列与 tasks 表一致，可作为 tasks 表的替代
+func taskVersionsAsOf(db *gorm.DB, asOf time.Time) *gorm.DB {
+	return db.Model(&TaskVersion{}).
+		Select("task_id AS id, title, description, category, color, due_date, status, version, created_at, updated_at, deleted_at").
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", asOf, asOf)
+}
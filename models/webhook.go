@@ -0,0 +1,163 @@
+package models
+
+import (
+	"E-Todo/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投递或重试
+	WebhookDeliverySucceeded = "succeeded" // 投递成功
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽，不再投递
+)
+
+// Webhook 事件订阅，任务发生变更时向 URL 推送签名的事件
+type Webhook struct {
+	ID         uint      `gorm:"primaryKey"`
+	URL        string    `gorm:"size:2048;not null"`
+	Secret     string    `gorm:"size:255;not null"` // HMAC-SHA256 签名密钥
+	EventTypes string    `gorm:"size:500;not null"` // 订阅的事件类型，逗号分隔
+	Active     bool      `gorm:"not null;default:true"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+// Events 返回订阅的事件类型
+func (w *Webhook) Events() []string {
+	if w.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(w.EventTypes, ",")
+}
+
+// SetEvents 设置订阅的事件类型
+func (w *Webhook) SetEvents(eventTypes []string) {
+	w.EventTypes = strings.Join(eventTypes, ",")
+}
+
+// Subscribes 判断是否订阅了指定事件类型
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, t := range w.Events() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Create 创建订阅
+func (w *Webhook) Create() error {
+	return config.DB.Create(w).Error
+}
+
+// FetchAll 获取全部订阅
+func (w *Webhook) FetchAll() ([]Webhook, error) {
+	var webhooks []Webhook
+	err := config.DB.Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FetchActive 获取全部启用的订阅
+func (w *Webhook) FetchActive() ([]Webhook, error) {
+	var webhooks []Webhook
+	err := config.DB.Where("active = ?", true).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FetchByID 根据 ID 获取订阅
+func (w *Webhook) FetchByID(id uint) error {
+	return config.DB.First(w, id).Error
+}
+
+// Update 更新订阅的字段
+func (w *Webhook) Update(updates map[string]interface{}) error {
+	return config.DB.Model(w).Updates(updates).Error
+}
+
+// Delete 删除订阅及其投递记录
+func (w *Webhook) Delete() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", w.ID).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(w).Error
+	})
+}
+
+// WebhookDelivery 一次事件投递，同时作为待投递队列和投递日志
+type WebhookDelivery struct {
+	ID             uint64     `gorm:"primaryKey"`
+	WebhookID      uint       `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventID        uint64     `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event"` // 同一事件对同一订阅只投递一次
+	EventType      string     `gorm:"size:50;not null"`
+	Payload        string     `gorm:"type:text;not null"` // 推送的请求体，JSON
+	Status         string     `gorm:"size:20;not null;index:idx_webhook_deliveries_due"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_deliveries_due"`
+	ResponseStatus int        // 最近一次投递的 HTTP 状态码，未收到响应时为 0
+	LastError      string     `gorm:"size:1000"`
+	DeliveredAt    *time.Time // 投递成功的时间
+	CreatedAt      time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime"`
+}
+
+// Enqueue 将投递加入队列，同一事件已加入过时忽略
+func (d *WebhookDelivery) Enqueue(deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// FetchDue 获取到期待投递的记录，最多 limit 条
+func (d *WebhookDelivery) FetchDue(now time.Time, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := config.DB.Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Claim 占用到期的投递直到 until，防止多个实例重复投递，已被其他实例占用时返回 false
+func (d *WebhookDelivery) Claim(now, until time.Time) (bool, error) {
+	result := config.DB.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", d.ID, WebhookDeliveryPending, now).
+		Update("next_attempt_at", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Record 记录一次投递的结果
+func (d *WebhookDelivery) Record(updates map[string]interface{}) error {
+	return config.DB.Model(d).Updates(updates).Error
+}
+
+// FetchByWebhook 按时间倒序分页获取订阅的投递记录
+func (d *WebhookDelivery) FetchByWebhook(webhookID uint, page, limit int) ([]WebhookDelivery, int64, error) {
+	var deliveries []WebhookDelivery
+	var total int64
+
+	query := config.DB.Model(&WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Scopes(Paginate(page, limit)).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// FetchByID 根据 ID 获取订阅的投递记录
+func (d *WebhookDelivery) FetchByID(webhookID uint, id uint64) error {
+	return config.DB.Where("webhook_id = ?", webhookID).First(d, id).Error
+}
+
+// Redeliver 重置投递状态，立即重新投递
+func (d *WebhookDelivery) Redeliver(now time.Time) error {
+	return config.DB.Model(d).Updates(map[string]interface{}{
+		"status":          WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"delivered_at":    nil,
+	}).Error
+}
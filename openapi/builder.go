@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation 描述一个路由，用于生成 OpenAPI 文档
+type Operation struct {
+	Method     string                 // HTTP 方法
+	Path       string                 // gin 风格的完整路径，如 /api/v1/tasks/:id
+	Summary    string                 // 接口说明
+	Tags       []string               // 分组
+	Query      interface{}            // 查询参数结构体（form 标签）
+	Body       interface{}            // JSON 请求体结构体（json 与 binding 标签）
+	BodyTypes  map[string]interface{} // 支持多种内容类型的请求体，key 为 Content-Type
+	Response   interface{}            // 成功响应中 data 字段的类型，nil 表示无数据
+	Status     int                    // 成功时的 HTTP 状态码，默认 200
+	Headers    []Parameter            // 额外的请求头参数
+	Deprecated bool                   // 是否已弃用
+	Stream     string                 // 非 JSON 的流式响应类型，如 text/event-stream
+}
+
+// Builder 逐个添加路由并生成 OpenAPI 文档
+type Builder struct {
+	doc     *Document
+	Problem interface{} // RFC 7807 错误响应结构体，非空时为每个接口添加 application/problem+json 错误响应
+	envName string
+}
+
+// NewBuilder 创建文档生成器，envelope 为统一响应结构体（其中的 data 字段会按接口替换为具体类型）
+func NewBuilder(title, version string, envelope interface{}) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI:    "3.0.3",
+			Info:       Info{Title: title, Version: version},
+			Paths:      map[string]PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+	}
+	if envelope != nil {
+		b.schemaFor(reflect.TypeOf(envelope))
+		b.envName = reflect.TypeOf(envelope).Name()
+	}
+	return b
+}
+
+// Add 添加一个路由
+func (b *Builder) Add(op Operation) {
+	path, pathParams := convertPath(op.Path)
+	method := strings.ToLower(op.Method)
+
+	operation := &OperationObject{
+		Summary:     op.Summary,
+		OperationID: operationID(op.Method, op.Path),
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Responses:   map[string]*Response{},
+	}
+
+	// id 及以 _id 结尾的路径参数为正整数，其他为字符串
+	for _, name := range pathParams {
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schema = &Schema{Type: "integer", Minimum: float(1)}
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	if op.Query != nil {
+		operation.Parameters = append(operation.Parameters, b.queryParameters(reflect.TypeOf(op.Query))...)
+	}
+	operation.Parameters = append(operation.Parameters, op.Headers...)
+
+	if op.Body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(op.Body))}},
+		}
+	}
+	if len(op.BodyTypes) > 0 {
+		operation.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		for contentType, body := range op.BodyTypes {
+			operation.RequestBody.Content[contentType] = MediaType{Schema: b.schemaFor(reflect.TypeOf(body))}
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	operation.Responses[strconv.Itoa(status)] = b.successResponse(op)
+	if b.envName != "" || b.Problem != nil {
+		operation.Responses["default"] = b.errorResponse()
+	}
+
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = PathItem{}
+	}
+	b.doc.Paths[path][method] = operation
+}
+
+// Document 返回生成的文档
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// successResponse 成功响应：统一响应结构体，其 data 字段为接口的具体类型
+func (b *Builder) successResponse(op Operation) *Response {
+	if op.Stream != "" {
+		return &Response{
+			Description: "Stream",
+			Content:     map[string]MediaType{op.Stream: {Schema: &Schema{Type: "string"}}},
+		}
+	}
+
+	var data *Schema
+	if op.Response != nil {
+		data = b.schemaFor(reflect.TypeOf(op.Response))
+	}
+
+	schema := data
+	if b.envName != "" {
+		schema = &Schema{AllOf: []*Schema{{Ref: "#/components/schemas/" + b.envName}}}
+		if data != nil {
+			schema.AllOf = append(schema.AllOf, &Schema{Type: "object", Properties: map[string]*Schema{"data": data}})
+		}
+	}
+
+	response := &Response{Description: "Success"}
+	if schema != nil {
+		response.Content = map[string]MediaType{"application/json": {Schema: schema}}
+	}
+	return response
+}
+
+// errorResponse 错误响应
+func (b *Builder) errorResponse() *Response {
+	response := &Response{Description: "Error", Content: map[string]MediaType{}}
+	if b.envName != "" {
+		response.Content["application/json"] = MediaType{Schema: &Schema{Ref: "#/components/schemas/" + b.envName}}
+	}
+	if b.Problem != nil {
+		response.Content["application/problem+json"] = MediaType{Schema: b.schemaFor(reflect.TypeOf(b.Problem))}
+	}
+	return response
+}
+
+// convertPath 将 gin 风格的路径参数 :id 转换为 OpenAPI 风格的 {id}
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 根据方法和路径生成唯一的 operationId
+func operationID(method, path string) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", "-", "_", "{", "", "}", "")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(path), "_")
+}
+
+// CheckRoutes 检查 gin 中注册的路由与文档是否一致，ignore 返回 true 的路径不参与检查
+func CheckRoutes(doc *Document, routes gin.RoutesInfo, ignore func(path string) bool) error {
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var undocumented, stale []string
+	registered := map[string]bool{}
+	for _, route := range routes {
+		if ignore != nil && ignore(route.Path) {
+			continue
+		}
+		path, _ := convertPath(route.Path)
+		key := route.Method + " " + path
+		registered[key] = true
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	if len(undocumented) == 0 && len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+	return fmt.Errorf("openapi: routes and spec have drifted; undocumented routes: %v; documented but not registered: %v", undocumented, stale)
+}
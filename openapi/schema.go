@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor 根据 Go 类型生成 Schema，具名结构体注册到 components 并返回引用
+func (b *Builder) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == nil:
+		return &Schema{}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := b.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// 先占位，避免递归类型无限展开
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// interface{} 等任意类型
+		return &Schema{}
+	}
+}
+
+// structSchema 展开结构体字段，匿名嵌入的结构体字段合并到外层
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(field.Type)
+			for name, prop := range embedded.Properties {
+				schema.Properties[name] = prop
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tagName(field, "json")
+		if name == "-" {
+			continue
+		}
+
+		prop := b.schemaFor(field.Type)
+		if applyBinding(prop, field) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+// queryParameters 根据带 form 标签的结构体生成查询参数
+func (b *Builder) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := tagName(field, "form")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		schema := b.schemaFor(field.Type)
+		required := applyBinding(schema, field)
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+// tagName 返回字段在指定标签中的名称，未设置时使用字段名
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// applyBinding 将 binding 标签中的校验规则写入 Schema，返回字段是否必填
+func applyBinding(schema *Schema, field reflect.StructField) bool {
+	required := false
+	target := schema
+	if len(schema.AllOf) > 0 {
+		target = &Schema{}
+	}
+
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(target.Type, value))
+			}
+		case "gte", "gt", "lte", "lt", "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyLimit(target, name, n)
+		}
+	}
+	return required
+}
+
+// applyLimit 根据字段类型将 gte/lte/min/max 规则转换为对应的 Schema 约束
+func applyLimit(schema *Schema, rule string, n float64) {
+	lower := rule == "gte" || rule == "gt" || rule == "min"
+	switch schema.Type {
+	case "array":
+		count := int(n)
+		if lower {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	case "string":
+		length := int(n)
+		if lower {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+	default:
+		if rule == "gt" {
+			n++
+		} else if rule == "lt" {
+			n--
+		}
+		if lower {
+			schema.Minimum = float(n)
+		} else {
+			schema.Maximum = float(n)
+		}
+	}
+}
+
+// enumValue 按 Schema 类型转换 oneof 中的枚举值
+func enumValue(schemaType, value string) interface{} {
+	if schemaType == "integer" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func float(n float64) *float64 {
+	return &n
+}
@@ -0,0 +1,30 @@
+package openapi
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// swagger-ui 目录中为 swagger-ui-dist 5.18.2 的文件，升级时修改下载地址中的版本
+//go:generate sh -c "curl -fsSL https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-5.18.2.tgz | tar -xz -C swagger-ui --strip-components=1 package/swagger-ui.css package/swagger-ui-bundle.js package/LICENSE"
+
+//go:embed swagger.html
+var swaggerHTML string
+
+// swaggerUIFiles 嵌入的 swagger-ui-dist 静态文件
+//
+//go:embed swagger-ui
+var swaggerUIFiles embed.FS
+
+// SwaggerUI 返回加载指定文档地址的 Swagger UI 页面，assetsURL 为 SwaggerAssets 的访问地址
+func SwaggerUI(specURL, assetsURL string) string {
+	return strings.NewReplacer("{{SPEC_URL}}", specURL, "{{ASSETS_URL}}", assetsURL).Replace(swaggerHTML)
+}
+
+// SwaggerAssets 返回 Swagger UI 的 CSS 和 JS 文件
+func SwaggerAssets() http.FileSystem {
+	sub, _ := fs.Sub(swaggerUIFiles, "swagger-ui")
+	return http.FS(sub)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"E-Todo/controllers"
+	"E-Todo/dto"
+	"E-Todo/openapi"
+	"net/http"
+)
+
+// notificationRoutes 当前调用方（X-User）的通知相关的全部路由，只在带版本号的路由下提供，各版本共用
+func notificationRoutes() []apiRoute {
+	return []apiRoute{
+		{Method: http.MethodGet, Path: "", Handler: controllers.FetchNotifications, Doc: openapi.Operation{
+			Summary: "List the caller's notifications, newest first", Query: dto.FetchNotificationsReq{}, Headers: userHeader,
+			Response: dto.FetchNotificationsResp{},
+		}},
+		{Method: http.MethodPatch, Path: "/read", Handler: controllers.MarkAllNotificationsRead, Doc: openapi.Operation{
+			Summary: "Mark all of the caller's notifications as read", Headers: userHeader, Response: dto.MarkAllNotificationsReadResp{},
+		}},
+		{Method: http.MethodPatch, Path: "/:id/read", Handler: controllers.MarkNotificationRead, Doc: openapi.Operation{
+			Summary: "Mark a notification as read", Headers: userHeader, Response: dto.NotificationDTO{},
+		}},
+	}
+}
@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"E-Todo/middlewares"
+	"E-Todo/openapi"
+	"E-Todo/utils"
+	"strings"
+)
+
+const (
+	openAPIPath = "/openapi.json" // OpenAPI 文档地址
+	docsPath    = "/docs"         // Swagger UI 地址
+	assetsPath  = "/docs/assets"  // Swagger UI 静态文件地址
+	graphQLPath = "/graphql"      // GraphQL 地址
+)
+
+// ifMatchHeader 支持乐观锁的接口的 If-Match 请求头
+var ifMatchHeader = []openapi.Parameter{{
+	Name:        "If-Match",
+	In:          "header",
+	Description: `Expected task version as an ETag, e.g. "3"; a mismatch returns 412`,
+	Schema:      &openapi.Schema{Type: "string"},
+}}
+
+// lastEventIDHeader 事件流断线续传的 Last-Event-ID 请求头
+var lastEventIDHeader = []openapi.Parameter{{
+	Name:        "Last-Event-ID",
+	In:          "header",
+	Description: "Resume after this event ID",
+	Schema:      &openapi.Schema{Type: "string"},
+}}
+
+// userHeader 需要调用方标识的接口的 X-User 请求头
+var userHeader = []openapi.Parameter{{
+	Name:        "X-User",
+	In:          "header",
+	Description: "Caller identity",
+	Required:    true,
+	Schema:      &openapi.Schema{Type: "string"},
+}}
+
+// idempotencyKeyHeader 支持幂等的接口的 Idempotency-Key 请求头
+var idempotencyKeyHeader = openapi.Parameter{
+	Name:        middlewares.IdempotencyKeyHeader,
+	In:          "header",
+	Description: "Retries with the same key from the same caller (X-User) replay the first response",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// buildOpenAPI 根据路由表生成 OpenAPI 文档，包括各 API 版本和已弃用的旧路由
+func buildOpenAPI() *openapi.Document {
+	b := openapi.NewBuilder("E-Todo API", latestAPIVersion().Name, utils.Response{})
+	b.Problem = utils.ProblemDetails{}
+
+	for _, version := range apiVersions {
+		prefix := "/api/" + version.Name
+		addOperations(b, prefix+"/tasks", "tasks", taskRoutes(version.Handlers), version.Deprecated)
+		addOperations(b, prefix+"/webhooks", "webhooks", webhookRoutes(), version.Deprecated)
+		addOperations(b, prefix+"/operations", "operations", operationRoutes(), version.Deprecated)
+		addOperations(b, prefix+"/notifications", "notifications", notificationRoutes(), version.Deprecated)
+	}
+	addOperations(b, "/tasks", "tasks", legacyTaskRoutes(), true)
+
+	return b.Document()
+}
+
+// addOperations 将资源的路由添加到文档，prefix 为资源的完整路径
+func addOperations(b *openapi.Builder, prefix, tag string, routes []apiRoute, deprecated bool) {
+	for _, route := range routes {
+		op := route.Doc
+		op.Method = route.Method
+		op.Path = prefix + route.Path
+		op.Tags = []string{tag}
+		op.Deprecated = deprecated
+		if route.Idempotent {
+			op.Headers = append(append([]openapi.Parameter{}, op.Headers...), idempotencyKeyHeader)
+		}
+		b.Add(op)
+	}
+}
+
+// isUndocumentedRoute 不参与路由与文档一致性检查的路由：文档自身及其静态文件，以及通过内省提供文档的 GraphQL
+func isUndocumentedRoute(path string) bool {
+	return path == openAPIPath || path == docsPath || strings.HasPrefix(path, assetsPath+"/") || path == graphQLPath
+}
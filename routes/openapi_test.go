@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/openapi"
+	"E-Todo/services"
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// TestRoutesMatchSpec 注册的路由与 OpenAPI 文档一致
+func TestRoutesMatchSpec(t *testing.T) {
+	r := SetupRouter()
+	if err := openapi.CheckRoutes(buildOpenAPI(), r.Routes(), isUndocumentedRoute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRoutesOutsideTaskTable 不在任务路由表中的接口同样已注册且有文档（或明确不需要文档）
+func TestRoutesOutsideTaskTable(t *testing.T) {
+	r := SetupRouter()
+	spec := buildOpenAPI()
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	cases := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/api/v1/webhooks"},
+		{http.MethodPost, "/api/v1/webhooks"},
+		{http.MethodPost, "/api/v1/webhooks/:id/deliveries/:delivery_id/redeliver"},
+		{http.MethodGet, "/api/v1/operations"},
+		{http.MethodPost, "/api/v1/operations/:id/undo"},
+		{http.MethodGet, "/api/v1/notifications"},
+		{http.MethodPatch, "/api/v1/notifications/read"},
+		{http.MethodPatch, "/api/v1/notifications/:id/read"},
+		{http.MethodGet, "/tasks"},
+		{http.MethodPost, graphQLPath},
+		{http.MethodGet, openAPIPath},
+		{http.MethodGet, docsPath},
+		{http.MethodGet, assetsPath + "/*filepath"},
+	}
+	for _, tc := range cases {
+		if !registered[tc.method+" "+tc.path] {
+			t.Errorf("%s %s is not registered", tc.method, tc.path)
+			continue
+		}
+		if isUndocumentedRoute(tc.path) {
+			continue
+		}
+		item, ok := spec.Paths[specPath(tc.path)]
+		if !ok || item[strings.ToLower(tc.method)] == nil {
+			t.Errorf("%s %s is registered but not documented", tc.method, tc.path)
+		}
+	}
+}
+
+// TestCheckRoutesDetectsDrift 新增未写文档的路由或删除已有文档的路由时检查失败
+func TestCheckRoutesDetectsDrift(t *testing.T) {
+	r := SetupRouter()
+	r.GET("/api/v1/undocumented", func(c *gin.Context) {})
+	if err := openapi.CheckRoutes(buildOpenAPI(), r.Routes(), isUndocumentedRoute); err == nil || !strings.Contains(err.Error(), "/api/v1/undocumented") {
+		t.Fatalf("expected undocumented route to be reported, got %v", err)
+	}
+
+	spec := buildOpenAPI()
+	spec.Paths["/api/v1/tasks/{id}/stale"] = openapi.PathItem{"get": spec.Paths["/api/v1/tasks"]["get"]}
+	if err := openapi.CheckRoutes(spec, SetupRouter().Routes(), isUndocumentedRoute); err == nil || !strings.Contains(err.Error(), "/api/v1/tasks/{id}/stale") {
+		t.Fatalf("expected stale spec path to be reported, got %v", err)
+	}
+}
+
+// specPath 将 gin 风格的路径转换为文档中的路径
+func specPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// TestDocsServesVendoredAssets Swagger UI 页面从本服务加载嵌入的 swagger-ui-dist 静态文件
+func TestDocsServesVendoredAssets(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, docsPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d", docsPath, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "://") {
+		t.Errorf("Swagger UI page loads assets from another host:\n%s", w.Body.String())
+	}
+	for _, name := range []string{"swagger-ui.css", "swagger-ui-bundle.js", "LICENSE"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, assetsPath+"/"+name, nil))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("GET %s/%s: status %d, %d bytes; run go generate in openapi", assetsPath, name, w.Code, w.Body.Len())
+		}
+	}
+}
+
+// TestLegacyPatchSoftDeletes 未带版本号的 PATCH /tasks/:id 保持软删除语义，部分更新只在 /api/v1 中提供
+func TestLegacyPatchSoftDeletes(t *testing.T) {
+	testdb.Open(t)
+	r := SetupRouter()
+	task, err := services.CreateTask(context.Background(), dto.CreateTaskReq{Title: "a", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/tasks/%d", task.ID), strings.NewReader(`{"title":"b"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("legacy PATCH: status %d %s", w.Code, w.Body.String())
+	}
+	if _, err := services.GetTask(task.ID, nil); err == nil {
+		t.Errorf("task still active after legacy PATCH: %v", err)
+	}
+
+	spec := buildOpenAPI()
+	if op := spec.Paths["/tasks/{id}"]["patch"]; op == nil || op.RequestBody != nil {
+		t.Errorf("legacy PATCH documented as %+v, want soft delete without body", op)
+	}
+	if op := spec.Paths["/api/v1/tasks/{id}"]["patch"]; op == nil || op.RequestBody == nil {
+		t.Errorf("v1 PATCH documented as %+v, want partial update", op)
+	}
+}
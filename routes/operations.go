@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"E-Todo/controllers"
+	"E-Todo/dto"
+	"E-Todo/openapi"
+	"net/http"
+)
+
+// operationRoutes 任务操作及撤销相关的全部路由，只在带版本号的路由下提供，各版本共用
+func operationRoutes() []apiRoute {
+	return []apiRoute{
+		{Method: http.MethodGet, Path: "", Handler: controllers.FetchOperations, Doc: openapi.Operation{
+			Summary: "List recent task operations, newest first", Query: dto.FetchOperationsReq{}, Response: dto.FetchOperationsResp{},
+		}},
+		{Method: http.MethodGet, Path: "/:id", Handler: controllers.GetOperation, Doc: openapi.Operation{
+			Summary: "Get a task operation", Response: dto.OperationDTO{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/undo", Handler: controllers.UndoOperation, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Revert the tasks changed by an operation to their previous state", Body: dto.UndoOperationReq{}, Response: dto.UndoOperationResp{},
+		}},
+	}
+}
@@ -1,30 +1,68 @@
 package routes
 
 import (
+	"E-Todo/config"
 	"E-Todo/controllers"
+	"E-Todo/middlewares"
+	"E-Todo/openapi"
 	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
 )
 
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	registerValidatorTagNames()
 
-	tasks := r.Group("tasks")
-	{
-		tasks.POST("", controllers.CreateTask)
-		tasks.GET("", controllers.FetchAllTasks)
-		tasks.PUT("/:id", controllers.UpdateTask)
-		tasks.DELETE("/:id", controllers.DeleteTask)
-		tasks.PATCH("/:id", controllers.SoftDelete)
-		tasks.PATCH("/:id/restore", controllers.RestoreTask)
-		tasks.PATCH("/:id/complete", controllers.CompleteTask)
-
-		batchTasks := tasks.Group("batch")
-		{
-			batchTasks.DELETE("", controllers.BatchDeleteTasks)
-			batchTasks.PATCH("", controllers.BatchSoftDeleteTasks)
-			batchTasks.PATCH("complete", controllers.BatchCompleteTasks)
-			batchTasks.PATCH("restore", controllers.BatchRestoreTasks)
+	// 请求 ID 与调用方标识，用于任务变更历史
+	r.Use(middlewares.RequestContext(controllers.UserHeader))
+
+	// 创建和批量操作支持 Idempotency-Key 请求头，幂等键按调用方区分
+	idempotency := middlewares.Idempotency(config.IdempotencyTTL(), controllers.UserHeader)
+
+	// 带版本号的路由：/api/v1/...，各版本并存
+	api := r.Group("api")
+	for _, version := range apiVersions {
+		group := api.Group(version.Name, middlewares.APIVersion(version.Name))
+		if version.Deprecated {
+			group.Use(middlewares.Deprecated("/api/"+version.Name, "/api/"+latestAPIVersion().Name, version.Sunset))
 		}
+		registerRoutes(group.Group("tasks"), taskRoutes(version.Handlers), idempotency)
+		registerRoutes(group.Group("webhooks"), webhookRoutes(), idempotency)
+		registerRoutes(group.Group("operations"), operationRoutes(), idempotency)
+		registerRoutes(group.Group("notifications"), notificationRoutes(), idempotency)
+	}
+
+	// 未带版本号的旧路由，等同于 v1，已弃用
+	legacy := r.Group("", middlewares.APIVersion("v1"), middlewares.Deprecated("", "/api/v1", config.LegacyAPISunset()))
+	registerRoutes(legacy.Group("tasks"), legacyTaskRoutes(), idempotency)
+
+	// GraphQL
+	r.POST(graphQLPath, controllers.GraphQL)
+
+	// OpenAPI 文档与 Swagger UI，路由与文档的一致性由测试保证，这里只记录日志
+	spec := buildOpenAPI()
+	if err := openapi.CheckRoutes(spec, r.Routes(), isUndocumentedRoute); err != nil {
+		log.Printf("OpenAPI: %v", err)
 	}
+	r.GET(openAPIPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	r.GET(docsPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(openapi.SwaggerUI(openAPIPath, assetsPath)))
+	})
+	r.StaticFS(assetsPath, openapi.SwaggerAssets())
+
 	return r
 }
+
+// registerRoutes 在资源的路由组下注册路由
+func registerRoutes(r *gin.RouterGroup, routes []apiRoute, idempotency gin.HandlerFunc) {
+	for _, route := range routes {
+		handlers := []gin.HandlerFunc{route.Handler}
+		if route.Idempotent {
+			handlers = append([]gin.HandlerFunc{idempotency}, handlers...)
+		}
+		r.Handle(route.Method, route.Path, handlers...)
+	}
+}
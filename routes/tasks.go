@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"E-Todo/dto"
+	"E-Todo/openapi"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// apiRoute 路由，同时用于注册 gin 路由和生成 OpenAPI 文档
+type apiRoute struct {
+	Method     string            // HTTP 方法
+	Path       string            // 相对于所属资源（如 /tasks）的路径
+	Handler    gin.HandlerFunc   // 处理函数
+	Idempotent bool              // 是否支持 Idempotency-Key 请求头
+	Doc        openapi.Operation // 文档信息，Method 和 Path 在生成文档时填充
+}
+
+// getTaskQuery GET /tasks/:id 的查询参数，仅用于文档
+type getTaskQuery struct {
+	Expand []string `form:"expand"` // 需要展开的关联数据，支持逗号分隔
+}
+
+// liveQuery GET /tasks/live 的查询参数，仅用于文档
+type liveQuery struct {
+	User string `form:"user"` // 调用方标识，仅在请求中没有 X-User 请求头时使用（浏览器无法为 WebSocket 设置请求头）
+}
+
+// taskRoutes 任务相关的全部路由
+// 新增路由只需在此添加，gin 路由与 OpenAPI 文档会同时更新
+func taskRoutes(h taskHandlers) []apiRoute {
+	return []apiRoute{
+		{Method: http.MethodPost, Path: "", Handler: h.CreateTask, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Create a task", Body: dto.CreateTaskReq{}, Response: dto.TaskDTO{}, Status: http.StatusCreated,
+		}},
+		{Method: http.MethodGet, Path: "", Handler: h.FetchAllTasks, Doc: openapi.Operation{
+			Summary: "List tasks", Query: dto.FetchAllTasksReq{}, Response: dto.FetchAllTasksResp{},
+		}},
+		{Method: http.MethodGet, Path: "/events", Handler: h.TaskEvents, Doc: openapi.Operation{
+			Summary: "Stream task change events (Server-Sent Events)", Query: dto.TaskEventsReq{}, Headers: lastEventIDHeader,
+			Stream: "text/event-stream",
+		}},
+		{Method: http.MethodGet, Path: "/live", Handler: h.Live, Doc: openapi.Operation{
+			Summary: "WebSocket for live task events and presence", Query: liveQuery{}, Status: http.StatusSwitchingProtocols,
+		}},
+		{Method: http.MethodGet, Path: "/trash", Handler: h.FetchTrash, Doc: openapi.Operation{
+			Summary: "List soft-deleted tasks", Query: dto.FetchTrashReq{}, Response: dto.FetchAllTasksResp{},
+		}},
+		{Method: http.MethodDelete, Path: "/trash", Handler: h.EmptyTrash, Doc: openapi.Operation{
+			Summary: "Permanently delete all soft-deleted tasks", Response: dto.EmptyTrashResp{},
+		}},
+		{Method: http.MethodPost, Path: "/bulk-update", Handler: h.BulkUpdateTasks, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Update all tasks matching a filter", Body: dto.BulkUpdateTasksReq{}, Response: dto.BulkUpdateTasksResp{},
+		}},
+		{Method: http.MethodGet, Path: "/:id", Handler: h.GetTask, Doc: openapi.Operation{
+			Summary: "Get a task", Query: getTaskQuery{}, Response: dto.TaskDetailDTO{},
+		}},
+		{Method: http.MethodPut, Path: "/:id", Handler: h.UpdateTask, Doc: openapi.Operation{
+			Summary: "Replace a task", Headers: ifMatchHeader, Body: dto.UpdateTaskReq{}, Response: dto.TaskDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id", Handler: h.DeleteTask, Doc: openapi.Operation{
+			Summary: "Permanently delete a task", Headers: ifMatchHeader,
+		}},
+		{Method: http.MethodPatch, Path: "/:id", Handler: h.PatchTask, Doc: openapi.Operation{
+			Summary: "Partially update a task", Headers: ifMatchHeader, Response: dto.TaskDTO{},
+			BodyTypes: map[string]interface{}{
+				"application/merge-patch+json": map[string]interface{}{},
+				"application/json-patch+json":  []dto.JSONPatchOperation{},
+			},
+		}},
+		{Method: http.MethodGet, Path: "/:id/history", Handler: h.TaskHistory, Doc: openapi.Operation{
+			Summary: "List the change history of a task, oldest first", Query: dto.TaskHistoryReq{}, Response: dto.TaskHistoryResp{},
+		}},
+		{Method: http.MethodGet, Path: "/:id/comments", Handler: h.FetchComments, Doc: openapi.Operation{
+			Summary: "List the comments of a task with nested replies, oldest first", Query: dto.FetchCommentsReq{}, Response: dto.FetchCommentsResp{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/comments", Handler: h.CreateComment, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Comment on a task or reply to a comment", Body: dto.CreateCommentReq{}, Response: dto.CommentDTO{}, Status: http.StatusCreated,
+		}},
+		{Method: http.MethodPatch, Path: "/:id/comments/:comment_id", Handler: h.UpdateComment, Doc: openapi.Operation{
+			Summary: "Edit a comment", Body: dto.UpdateCommentReq{}, Response: dto.CommentDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id/comments/:comment_id", Handler: h.DeleteComment, Doc: openapi.Operation{
+			Summary: "Delete a comment and its replies",
+		}},
+		{Method: http.MethodGet, Path: "/:id/checklist", Handler: h.FetchChecklist, Doc: openapi.Operation{
+			Summary: "Get the checklist of a task with its progress", Response: dto.ChecklistDTO{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/checklist", Handler: h.AddChecklistItem, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Add an item to the checklist of a task", Body: dto.CreateChecklistItemReq{}, Response: dto.ChecklistItemDTO{}, Status: http.StatusCreated,
+		}},
+		{Method: http.MethodPut, Path: "/:id/checklist/order", Handler: h.ReorderChecklist, Doc: openapi.Operation{
+			Summary: "Reorder the checklist of a task", Body: dto.ReorderChecklistReq{}, Response: dto.ChecklistDTO{},
+		}},
+		{Method: http.MethodPatch, Path: "/:id/checklist/:item_id", Handler: h.UpdateChecklistItem, Doc: openapi.Operation{
+			Summary: "Edit or check/uncheck a checklist item", Body: dto.UpdateChecklistItemReq{}, Response: dto.ChecklistItemDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id/checklist/:item_id", Handler: h.DeleteChecklistItem, Doc: openapi.Operation{
+			Summary: "Remove a checklist item",
+		}},
+		{Method: http.MethodGet, Path: "/:id/members", Handler: h.FetchTaskMembers, Doc: openapi.Operation{
+			Summary: "List the assignees and watchers of a task", Response: dto.TaskMembersDTO{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/assignees", Handler: h.AddAssignee, Doc: openapi.Operation{
+			Summary: "Assign a user (me by default) to a task", Body: dto.AddTaskMemberReq{}, Response: dto.TaskMembersDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id/assignees/:user", Handler: h.RemoveAssignee, Doc: openapi.Operation{
+			Summary: "Unassign a user (or me) from a task",
+		}},
+		{Method: http.MethodPost, Path: "/:id/watchers", Handler: h.AddWatcher, Doc: openapi.Operation{
+			Summary: "Watch a task as a user (me by default)", Body: dto.AddTaskMemberReq{}, Response: dto.TaskMembersDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id/watchers/:user", Handler: h.RemoveWatcher, Doc: openapi.Operation{
+			Summary: "Stop a user (or me) from watching a task",
+		}},
+		{Method: http.MethodGet, Path: "/:id/reminders", Handler: h.FetchReminders, Doc: openapi.Operation{
+			Summary: "List the reminders of a task in firing order", Response: []dto.ReminderDTO{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/reminders", Handler: h.CreateReminder, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Add a reminder at a time or relative to the due date", Body: dto.CreateReminderReq{}, Response: dto.ReminderDTO{}, Status: http.StatusCreated,
+		}},
+		{Method: http.MethodDelete, Path: "/:id/reminders/:reminder_id", Handler: h.DeleteReminder, Doc: openapi.Operation{
+			Summary: "Delete a reminder",
+		}},
+		{Method: http.MethodGet, Path: "/:id/attachments", Handler: h.FetchAttachments, Doc: openapi.Operation{
+			Summary: "List the attachments of a task", Response: dto.FetchAttachmentsResp{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/attachments", Handler: h.UploadAttachment, Doc: openapi.Operation{
+			Summary: "Upload an attachment to a task", Response: dto.AttachmentDTO{}, Status: http.StatusCreated,
+			BodyTypes: map[string]interface{}{"multipart/form-data": dto.UploadAttachmentForm{}},
+		}},
+		{Method: http.MethodGet, Path: "/:id/attachments/:attachment_id", Handler: h.GetAttachment, Doc: openapi.Operation{
+			Summary: "Get the metadata of an attachment", Response: dto.AttachmentDTO{},
+		}},
+		{Method: http.MethodGet, Path: "/:id/attachments/:attachment_id/content", Handler: h.DownloadAttachment, Doc: openapi.Operation{
+			Summary: "Download the content of an attachment", Stream: "application/octet-stream",
+		}},
+		{Method: http.MethodDelete, Path: "/:id/attachments/:attachment_id", Handler: h.DeleteAttachment, Doc: openapi.Operation{
+			Summary: "Delete an attachment",
+		}},
+		{Method: http.MethodPatch, Path: "/:id/soft-delete", Handler: h.SoftDelete, Doc: openapi.Operation{
+			Summary: "Move a task to the trash", Headers: ifMatchHeader,
+		}},
+		{Method: http.MethodPatch, Path: "/:id/restore", Handler: h.RestoreTask, Doc: openapi.Operation{
+			Summary: "Restore a task from the trash", Headers: ifMatchHeader,
+		}},
+		{Method: http.MethodPatch, Path: "/:id/complete", Handler: h.CompleteTask, Doc: openapi.Operation{
+			Summary: "Mark a task as completed", Headers: ifMatchHeader,
+		}},
+		{Method: http.MethodPost, Path: "/batch", Handler: h.ExecuteBatch, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Execute mixed operations in one transaction", Body: dto.BatchOperationsReq{}, Response: dto.BatchOperationsResp{},
+		}},
+		{Method: http.MethodDelete, Path: "/batch", Handler: h.BatchDeleteTasks, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Permanently delete tasks", Body: dto.BatchTaskActionReq{}, Response: dto.BatchTaskActionResp{},
+		}},
+		{Method: http.MethodPatch, Path: "/batch", Handler: h.BatchSoftDeleteTasks, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Move tasks to the trash", Body: dto.BatchTaskActionReq{}, Response: dto.BatchTaskActionResp{},
+		}},
+		{Method: http.MethodPatch, Path: "/batch/complete", Handler: h.BatchCompleteTasks, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Mark tasks as completed", Body: dto.BatchTaskActionReq{}, Response: dto.BatchTaskActionResp{},
+		}},
+		{Method: http.MethodPatch, Path: "/batch/restore", Handler: h.BatchRestoreTasks, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Restore tasks from the trash", Body: dto.BatchTaskActionReq{}, Response: dto.BatchTaskActionResp{},
+		}},
+	}
+}
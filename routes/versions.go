@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"E-Todo/controllers"
+	"E-Todo/openapi"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// taskHandlers 任务相关路由的处理函数
+// 新版本以上一版本为基础，只替换行为发生变化的处理函数
+type taskHandlers struct {
+	CreateTask           gin.HandlerFunc
+	FetchAllTasks        gin.HandlerFunc
+	TaskEvents           gin.HandlerFunc
+	Live                 gin.HandlerFunc
+	FetchTrash           gin.HandlerFunc
+	EmptyTrash           gin.HandlerFunc
+	BulkUpdateTasks      gin.HandlerFunc
+	GetTask              gin.HandlerFunc
+	UpdateTask           gin.HandlerFunc
+	DeleteTask           gin.HandlerFunc
+	PatchTask            gin.HandlerFunc
+	TaskHistory          gin.HandlerFunc
+	FetchComments        gin.HandlerFunc
+	CreateComment        gin.HandlerFunc
+	UpdateComment        gin.HandlerFunc
+	DeleteComment        gin.HandlerFunc
+	FetchChecklist       gin.HandlerFunc
+	AddChecklistItem     gin.HandlerFunc
+	UpdateChecklistItem  gin.HandlerFunc
+	ReorderChecklist     gin.HandlerFunc
+	DeleteChecklistItem  gin.HandlerFunc
+	FetchTaskMembers     gin.HandlerFunc
+	AddAssignee          gin.HandlerFunc
+	RemoveAssignee       gin.HandlerFunc
+	AddWatcher           gin.HandlerFunc
+	RemoveWatcher        gin.HandlerFunc
+	FetchReminders       gin.HandlerFunc
+	CreateReminder       gin.HandlerFunc
+	DeleteReminder       gin.HandlerFunc
+	FetchAttachments     gin.HandlerFunc
+	UploadAttachment     gin.HandlerFunc
+	GetAttachment        gin.HandlerFunc
+	DownloadAttachment   gin.HandlerFunc
+	DeleteAttachment     gin.HandlerFunc
+	SoftDelete           gin.HandlerFunc
+	RestoreTask          gin.HandlerFunc
+	CompleteTask         gin.HandlerFunc
+	ExecuteBatch         gin.HandlerFunc
+	BatchDeleteTasks     gin.HandlerFunc
+	BatchSoftDeleteTasks gin.HandlerFunc
+	BatchCompleteTasks   gin.HandlerFunc
+	BatchRestoreTasks    gin.HandlerFunc
+}
+
+// apiVersion 一个 API 版本
+type apiVersion struct {
+	Name       string       // 版本名，即路由前缀 /api/<Name>
+	Handlers   taskHandlers // 该版本的处理函数
+	Deprecated bool         // 已弃用的版本返回 Deprecation 响应头
+	Sunset     time.Time    // 已弃用版本的停用日期，零值表示未定
+}
+
+// apiVersions 所有并存的 API 版本，按从旧到新排列
+// 新增版本示例：
+//
+//	v2 := v1TaskHandlers()
+//	v2.GetTask = controllers.GetTaskV2
+//	apiVersions = append(apiVersions, apiVersion{Name: "v2", Handlers: v2})
+var apiVersions = []apiVersion{
+	{Name: "v1", Handlers: v1TaskHandlers()},
+}
+
+// latestAPIVersion 返回最新的 API 版本
+func latestAPIVersion() apiVersion {
+	return apiVersions[len(apiVersions)-1]
+}
+
+// v1TaskHandlers v1 版本的任务处理函数
+func v1TaskHandlers() taskHandlers {
+	return taskHandlers{
+		CreateTask:           controllers.CreateTask,
+		FetchAllTasks:        controllers.FetchAllTasks,
+		TaskEvents:           controllers.TaskEvents,
+		Live:                 controllers.Live,
+		FetchTrash:           controllers.FetchTrash,
+		EmptyTrash:           controllers.EmptyTrash,
+		BulkUpdateTasks:      controllers.BulkUpdateTasks,
+		GetTask:              controllers.GetTask,
+		UpdateTask:           controllers.UpdateTask,
+		DeleteTask:           controllers.DeleteTask,
+		PatchTask:            controllers.PatchTask,
+		TaskHistory:          controllers.TaskHistory,
+		FetchComments:        controllers.FetchComments,
+		CreateComment:        controllers.CreateComment,
+		UpdateComment:        controllers.UpdateComment,
+		DeleteComment:        controllers.DeleteComment,
+		FetchChecklist:       controllers.FetchChecklist,
+		AddChecklistItem:     controllers.AddChecklistItem,
+		UpdateChecklistItem:  controllers.UpdateChecklistItem,
+		ReorderChecklist:     controllers.ReorderChecklist,
+		DeleteChecklistItem:  controllers.DeleteChecklistItem,
+		FetchTaskMembers:     controllers.FetchTaskMembers,
+		AddAssignee:          controllers.AddAssignee,
+		RemoveAssignee:       controllers.RemoveAssignee,
+		AddWatcher:           controllers.AddWatcher,
+		RemoveWatcher:        controllers.RemoveWatcher,
+		FetchReminders:       controllers.FetchReminders,
+		CreateReminder:       controllers.CreateReminder,
+		DeleteReminder:       controllers.DeleteReminder,
+		FetchAttachments:     controllers.FetchAttachments,
+		UploadAttachment:     controllers.UploadAttachment,
+		GetAttachment:        controllers.GetAttachment,
+		DownloadAttachment:   controllers.DownloadAttachment,
+		DeleteAttachment:     controllers.DeleteAttachment,
+		SoftDelete:           controllers.SoftDelete,
+		RestoreTask:          controllers.RestoreTask,
+		CompleteTask:         controllers.CompleteTask,
+		ExecuteBatch:         controllers.ExecuteBatch,
+		BatchDeleteTasks:     controllers.BatchDeleteTasks,
+		BatchSoftDeleteTasks: controllers.BatchSoftDeleteTasks,
+		BatchCompleteTasks:   controllers.BatchCompleteTasks,
+		BatchRestoreTasks:    controllers.BatchRestoreTasks,
+	}
+}
+
+// legacyTaskRoutes 未带版本号的旧路由，与 v1 相同，
+// 但 PATCH /tasks/:id 保持原有的软删除语义，部分更新只在带版本号的路由中提供
+func legacyTaskRoutes() []apiRoute {
+	routes := taskRoutes(v1TaskHandlers())
+	for i, route := range routes {
+		if route.Method == http.MethodPatch && route.Path == "/:id" {
+			routes[i].Handler = controllers.SoftDelete
+			routes[i].Doc = openapi.Operation{
+				Summary: "Move a task to the trash (same as PATCH /tasks/{id}/soft-delete)", Headers: ifMatchHeader,
+			}
+		}
+	}
+	return routes
+}
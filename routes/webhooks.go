@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"E-Todo/controllers"
+	"E-Todo/dto"
+	"E-Todo/openapi"
+	"net/http"
+)
+
+// webhookRoutes Webhook 订阅相关的全部路由，只在带版本号的路由下提供，各版本共用
+func webhookRoutes() []apiRoute {
+	return []apiRoute{
+		{Method: http.MethodPost, Path: "", Handler: controllers.CreateWebhook, Idempotent: true, Doc: openapi.Operation{
+			Summary: "Create a webhook subscription", Body: dto.CreateWebhookReq{}, Response: dto.WebhookDTO{}, Status: http.StatusCreated,
+		}},
+		{Method: http.MethodGet, Path: "", Handler: controllers.FetchWebhooks, Doc: openapi.Operation{
+			Summary: "List webhook subscriptions", Response: []dto.WebhookDTO{},
+		}},
+		{Method: http.MethodGet, Path: "/:id", Handler: controllers.GetWebhook, Doc: openapi.Operation{
+			Summary: "Get a webhook subscription", Response: dto.WebhookDTO{},
+		}},
+		{Method: http.MethodPatch, Path: "/:id", Handler: controllers.UpdateWebhook, Doc: openapi.Operation{
+			Summary: "Update a webhook subscription", Body: dto.UpdateWebhookReq{}, Response: dto.WebhookDTO{},
+		}},
+		{Method: http.MethodDelete, Path: "/:id", Handler: controllers.DeleteWebhook, Doc: openapi.Operation{
+			Summary: "Delete a webhook subscription and its delivery log",
+		}},
+		{Method: http.MethodGet, Path: "/:id/deliveries", Handler: controllers.FetchWebhookDeliveries, Doc: openapi.Operation{
+			Summary: "List deliveries of a webhook, newest first", Query: dto.WebhookDeliveriesReq{}, Response: dto.WebhookDeliveriesResp{},
+		}},
+		{Method: http.MethodPost, Path: "/:id/deliveries/:delivery_id/redeliver", Handler: controllers.RedeliverWebhook, Doc: openapi.Operation{
+			Summary: "Queue a delivery for immediate redelivery", Response: dto.WebhookDeliveryDTO{},
+		}},
+	}
+}
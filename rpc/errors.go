@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"E-Todo/apperrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// grpcCodes 错误码对应的 gRPC 状态码，错误码目录中的每个错误码都需要对应
+var grpcCodes = map[apperrors.Code]codes.Code{
+	apperrors.CodeValidation:            codes.InvalidArgument,
+	apperrors.CodeInternal:              codes.Internal,
+	apperrors.CodeBatchAborted:          codes.Aborted,
+	apperrors.CodeVersionConflict:       codes.FailedPrecondition,
+	apperrors.CodeNotFound:              codes.NotFound,
+	apperrors.CodeIdempotencyKeyReused:  codes.InvalidArgument,
+	apperrors.CodeIdempotencyInProgress: codes.Aborted,
+	apperrors.CodeConflict:              codes.FailedPrecondition,
+	apperrors.CodeForbidden:             codes.PermissionDenied,
+	apperrors.CodeQueryTooComplex:       codes.InvalidArgument,
+	apperrors.CodeUndoExpired:           codes.FailedPrecondition,
+	apperrors.CodePayloadTooLarge:       codes.ResourceExhausted,
+}
+
+// toStatus 将服务层错误转换为 gRPC 状态
+// 错误码放在 ErrorInfo 中，字段级错误放在 BadRequest 中，extra 为附加的详情（如批量操作中止时各任务的结果，对应 HTTP 响应的 data）；
+// 内部错误只记录日志，不向客户端暴露细节
+func toStatus(method string, err error, extra ...protoadapt.MessageV1) error {
+	appErr := apperrors.From(err)
+
+	msg := appErr.Message
+	if appErr.Code == apperrors.CodeInternal {
+		log.Printf("grpc %s: %v", method, err)
+		msg = apperrors.CodeInternal.Title
+	}
+
+	code, ok := grpcCodes[appErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   appErr.Code.Name,
+		Domain:   "e-todo",
+		Metadata: map[string]string{"code": strconv.Itoa(appErr.Code.Num)},
+	}}
+	if len(appErr.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range sortedKeys(appErr.Fields) {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: appErr.Fields[field],
+			})
+		}
+		details = append(details, badRequest)
+	}
+	details = append(details, extra...)
+
+	st := status.New(code, msg)
+	if withDetails, detailErr := st.WithDetails(details...); detailErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// sortedKeys 按字母顺序返回 map 的 key
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,307 @@
+package rpc
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	taskv1 "E-Todo/pb/task/v1"
+	"E-Todo/services"
+	"context"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"net"
+	"strconv"
+)
+
+// defaultPageLimit 未指定 limit 时每页的任务数量，与 HTTP 接口一致
+const defaultPageLimit = 50
+
+// TaskServer 实现 taskv1.TaskServiceServer，业务逻辑全部委托给 services 层
+type TaskServer struct {
+	taskv1.UnimplementedTaskServiceServer
+}
+
+// 调用方信息的请求元数据，与 HTTP 接口的 X-User、X-Request-ID 请求头对应
+const (
+	userMetadata      = "x-user"
+	requestIDMetadata = "x-request-id"
+)
+
+// NewServer 创建注册了 TaskService 的 gRPC 服务
+func NewServer() *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(callerInterceptor), grpc.StreamInterceptor(callerStreamInterceptor))
+	taskv1.RegisterTaskServiceServer(server, &TaskServer{})
+	return server
+}
+
+// Start 在指定地址后台启动 gRPC 服务
+func Start(addr string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("gRPC server failed to listen on %s: %v", addr, err)
+	}
+
+	go func() {
+		log.Printf("gRPC server listening on %s", addr)
+		if err := NewServer().Serve(lis); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+}
+
+// callerInterceptor 将请求元数据中的调用方标识和请求 ID 放入 context，用于记录任务变更历史
+func callerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return handler(services.WithCaller(ctx, firstMetadata(md, userMetadata), firstMetadata(md, requestIDMetadata)), req)
+}
+
+// callerStreamInterceptor 与 callerInterceptor 相同，用于流式 RPC
+func callerStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	ctx := services.WithCaller(ss.Context(), firstMetadata(md, userMetadata), firstMetadata(md, requestIDMetadata))
+	return handler(srv, &callerStream{ServerStream: ss, ctx: ctx})
+}
+
+// callerStream 替换 ServerStream 的 context
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回带有调用方信息的 context
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
+
+// firstMetadata 返回元数据中指定键的第一个值
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// CreateTask 创建任务
+func (s *TaskServer) CreateTask(ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.CreateTaskResponse, error) {
+	createReq := dto.CreateTaskReq{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Category:    req.GetCategory(),
+		Color:       req.GetColor(),
+		DueDate:     req.GetDueDate(),
+	}
+	if err := binding.Validator.ValidateStruct(&createReq); err != nil {
+		return nil, toStatus("CreateTask", apperrors.Validation(err))
+	}
+
+	task, err := services.CreateTask(ctx, createReq)
+	if err != nil {
+		return nil, toStatus("CreateTask", err)
+	}
+	return &taskv1.CreateTaskResponse{Task: toTask(task)}, nil
+}
+
+// GetTask 获取单个任务
+func (s *TaskServer) GetTask(ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.GetTaskResponse, error) {
+	task, err := services.GetTask(uint(req.GetId()), nil)
+	if err != nil {
+		return nil, toStatus("GetTask", err)
+	}
+	return &taskv1.GetTaskResponse{Task: toTask(task.TaskDTO)}, nil
+}
+
+// ListTasks 逐个推送符合条件的任务
+// 指定 page 时只推送该页，否则按 limit 分页依次推送全部任务
+func (s *TaskServer) ListTasks(req *taskv1.ListTasksRequest, stream grpc.ServerStreamingServer[taskv1.ListTasksResponse]) error {
+	listReq := dto.FetchAllTasksReq{
+		Page:          int(req.GetPage()),
+		Limit:         int(req.GetLimit()),
+		KeyWords:      req.GetKeywords(),
+		Category:      req.GetCategory(),
+		Status:        req.GetStatus(),
+		Color:         req.GetColor(),
+		RemainingDays: int(req.GetRemainingDays()),
+		Sort:          req.GetSort(),
+		Assignee:      req.GetAssignee(),
+		Watcher:       req.GetWatcher(),
+		AsOf:          req.GetAsOf(),
+	}
+	if err := binding.Validator.ValidateStruct(&listReq); err != nil {
+		return toStatus("ListTasks", apperrors.Validation(err))
+	}
+
+	// 设置默认值
+	all := listReq.Page <= 0
+	if all {
+		listReq.Page = 1
+	}
+	if listReq.Limit <= 0 {
+		listReq.Limit = defaultPageLimit
+	}
+
+	for first := true; ; first = false {
+		tasks, total, err := services.FetchAllTasks(stream.Context(), listReq)
+		if err != nil {
+			return toStatus("ListTasks", err)
+		}
+		if first {
+			if err = stream.SendHeader(metadata.Pairs("x-total-count", strconv.FormatInt(total, 10))); err != nil {
+				return err
+			}
+		}
+
+		for _, task := range tasks {
+			if err = stream.Send(&taskv1.ListTasksResponse{Task: toTask(task)}); err != nil {
+				return err
+			}
+		}
+
+		if !all || len(tasks) < listReq.Limit {
+			return nil
+		}
+		if err = stream.Context().Err(); err != nil {
+			return err
+		}
+		listReq.Page++
+	}
+}
+
+// UpdateTask 更新任务
+func (s *TaskServer) UpdateTask(ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.UpdateTaskResponse, error) {
+	updateReq := dto.UpdateTaskReq{
+		ID:          uint(req.GetId()),
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Category:    req.GetCategory(),
+		Color:       req.GetColor(),
+		DueDate:     req.GetDueDate(),
+		Status:      req.GetStatus(),
+		Version:     uint(req.GetVersion()),
+	}
+	if err := binding.Validator.ValidateStruct(&updateReq); err != nil {
+		return nil, toStatus("UpdateTask", apperrors.Validation(err))
+	}
+
+	task, err := services.UpdateTask(ctx, updateReq)
+	if err != nil {
+		return nil, toStatus("UpdateTask", err)
+	}
+	return &taskv1.UpdateTaskResponse{Task: toTask(task)}, nil
+}
+
+// CompleteTask 完成任务
+func (s *TaskServer) CompleteTask(ctx context.Context, req *taskv1.CompleteTaskRequest) (*taskv1.CompleteTaskResponse, error) {
+	task, err := applyTaskAction(ctx, services.CompleteTask, req.GetId(), req.GetVersion())
+	if err != nil {
+		return nil, toStatus("CompleteTask", err)
+	}
+	return &taskv1.CompleteTaskResponse{Task: task}, nil
+}
+
+// SoftDeleteTask 软删除任务
+func (s *TaskServer) SoftDeleteTask(ctx context.Context, req *taskv1.SoftDeleteTaskRequest) (*taskv1.SoftDeleteTaskResponse, error) {
+	if err := services.SoftDelete(ctx, uint(req.GetId()), uint(req.GetVersion())); err != nil {
+		return nil, toStatus("SoftDeleteTask", err)
+	}
+	return &taskv1.SoftDeleteTaskResponse{}, nil
+}
+
+// RestoreTask 恢复软删除的任务
+func (s *TaskServer) RestoreTask(ctx context.Context, req *taskv1.RestoreTaskRequest) (*taskv1.RestoreTaskResponse, error) {
+	task, err := applyTaskAction(ctx, services.RestoreTask, req.GetId(), req.GetVersion())
+	if err != nil {
+		return nil, toStatus("RestoreTask", err)
+	}
+	return &taskv1.RestoreTaskResponse{Task: task}, nil
+}
+
+// BatchDeleteTasks 批量删除任务
+func (s *TaskServer) BatchDeleteTasks(ctx context.Context, req *taskv1.BatchTaskActionRequest) (*taskv1.BatchTaskActionResponse, error) {
+	return batch(ctx, "BatchDeleteTasks", services.BatchDeleteTasks, req)
+}
+
+// BatchCompleteTasks 批量完成任务
+func (s *TaskServer) BatchCompleteTasks(ctx context.Context, req *taskv1.BatchTaskActionRequest) (*taskv1.BatchTaskActionResponse, error) {
+	return batch(ctx, "BatchCompleteTasks", services.BatchCompleteTasks, req)
+}
+
+// BatchSoftDeleteTasks 批量软删除任务
+func (s *TaskServer) BatchSoftDeleteTasks(ctx context.Context, req *taskv1.BatchTaskActionRequest) (*taskv1.BatchTaskActionResponse, error) {
+	return batch(ctx, "BatchSoftDeleteTasks", services.BatchSoftDeleteTasks, req)
+}
+
+// BatchRestoreTasks 批量恢复任务
+func (s *TaskServer) BatchRestoreTasks(ctx context.Context, req *taskv1.BatchTaskActionRequest) (*taskv1.BatchTaskActionResponse, error) {
+	return batch(ctx, "BatchRestoreTasks", services.BatchRestoreTasks, req)
+}
+
+// applyTaskAction 对单个任务执行操作，成功后返回最新的任务
+func applyTaskAction(ctx context.Context, action func(ctx context.Context, id uint, version uint) error, id, version uint64) (*taskv1.Task, error) {
+	if err := action(ctx, uint(id), uint(version)); err != nil {
+		return nil, err
+	}
+	task, err := services.GetTask(uint(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task.TaskDTO), nil
+}
+
+// batch 执行批量任务操作
+func batch(ctx context.Context, method string, action func(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error), req *taskv1.BatchTaskActionRequest) (*taskv1.BatchTaskActionResponse, error) {
+	batchReq := dto.BatchTaskActionReq{Atomic: req.GetAtomic()}
+	for _, id := range req.GetIds() {
+		batchReq.IDs = append(batchReq.IDs, uint(id))
+	}
+	if len(batchReq.IDs) == 0 {
+		return nil, toStatus(method, apperrors.InvalidField("ids", "is required"))
+	}
+
+	resp, err := action(ctx, batchReq)
+	if err != nil {
+		// 事务模式中止时与 HTTP 接口一样返回各任务的结果
+		if len(resp.Results) > 0 {
+			return nil, toStatus(method, err, toBatchResponse(resp))
+		}
+		return nil, toStatus(method, err)
+	}
+	return toBatchResponse(resp), nil
+}
+
+// toTask 将 dto.TaskDTO 转换为 protobuf 消息
+func toTask(task dto.TaskDTO) *taskv1.Task {
+	message := &taskv1.Task{
+		Id:           uint64(task.ID),
+		Title:        task.Title,
+		Description:  task.Description,
+		Category:     task.Category,
+		Color:        task.Color,
+		DueDate:      task.DueDate,
+		Status:       task.Status,
+		Version:      uint64(task.Version),
+		CommentCount: int32(task.CommentCount),
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+		DeletedAt:    task.DeletedAt,
+	}
+	if task.Progress != nil {
+		progress := int32(*task.Progress)
+		message.Progress = &progress
+	}
+	return message
+}
+
+// toBatchResponse 将批量操作结果转换为 protobuf 消息
+func toBatchResponse(resp dto.BatchTaskActionResp) *taskv1.BatchTaskActionResponse {
+	results := make([]*taskv1.BatchItemResult, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		results = append(results, &taskv1.BatchItemResult{Id: uint64(result.ID), Result: result.Result})
+	}
+	return &taskv1.BatchTaskActionResponse{
+		Results:   results,
+		Succeeded: int32(resp.Succeeded),
+		Failed:    int32(resp.Failed),
+		Affected:  resp.Affected,
+	}
+}
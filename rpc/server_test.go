@@ -0,0 +1,462 @@
+package rpc
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	taskv1 "E-Todo/pb/task/v1"
+	"E-Todo/routes"
+	"E-Todo/services"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// callError 两种接口返回的错误，只比较错误码和字段级错误
+type callError struct {
+	Code   int
+	Fields map[string]string
+}
+
+// transport 以相同的方式调用 HTTP 或 gRPC 接口，结果统一转换为 protobuf 消息以便比较
+type transport interface {
+	create(req dto.CreateTaskReq) (*taskv1.Task, *callError)
+	list(status string) ([]*taskv1.Task, *callError)
+	complete(id uint64) (*taskv1.Task, *callError)
+	batchComplete(ids []uint64, atomic bool) (*taskv1.BatchTaskActionResponse, *callError)
+}
+
+// observation 一次调用的结果
+type observation struct {
+	Step  string
+	Value proto.Message
+	Tasks []*taskv1.Task
+	Err   *callError
+}
+
+// runScenario 在新的数据库上依次执行创建、列表、完成和批量操作，记录每一步的结果
+func runScenario(t *testing.T, newTransport func(t *testing.T) transport) []observation {
+	testdb.Open(t)
+	tr := newTransport(t)
+
+	var obs []observation
+	record := func(step string, value proto.Message, err *callError) {
+		obs = append(obs, observation{Step: step, Value: value, Err: err})
+	}
+
+	for i, req := range []dto.CreateTaskReq{
+		{Title: "write report", Description: "quarterly", Category: "work", Color: "red", DueDate: "2030-01-01T10:00Z"},
+		{Title: "buy milk", DueDate: "2030-01-02T10:00Z"},
+		{Title: "call bob", Color: "blue", DueDate: "2030-01-03T10:00Z"},
+		{Title: "", DueDate: "2030-01-03T10:00Z"},
+		{Title: "bad date", DueDate: "tomorrow"},
+	} {
+		task, err := tr.create(req)
+		record("create "+strconv.Itoa(i), task, err)
+	}
+
+	// 评论数和检查项进度同样需要在两种接口中一致
+	alice := services.WithCaller(context.Background(), "alice", "")
+	if _, err := services.CreateComment(alice, 1, dto.CreateCommentReq{Body: "looks good"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.AddChecklistItem(alice, 1, dto.CreateChecklistItemReq{Text: "draft", Checked: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := tr.list("")
+	obs = append(obs, observation{Step: "list", Tasks: tasks, Err: err})
+
+	task, err := tr.complete(1)
+	record("complete", task, err)
+	task, err = tr.complete(1)
+	record("complete again", task, err)
+	task, err = tr.complete(99)
+	record("complete missing", task, err)
+
+	tasks, err = tr.list("completed")
+	obs = append(obs, observation{Step: "list completed", Tasks: tasks, Err: err})
+
+	resp, err := tr.batchComplete([]uint64{2, 99}, true)
+	record("batch atomic abort", resp, err)
+	resp, err = tr.batchComplete([]uint64{2, 1, 99}, false)
+	record("batch best effort", resp, err)
+	resp, err = tr.batchComplete(nil, false)
+	record("batch empty", resp, err)
+
+	tasks, err = tr.list("")
+	obs = append(obs, observation{Step: "list after batch", Tasks: tasks, Err: err})
+	return obs
+}
+
+// TestRESTAndGRPCParity HTTP 与 gRPC 接口对相同的调用返回相同的数据和错误码
+func TestRESTAndGRPCParity(t *testing.T) {
+	rest := runScenario(t, newRESTTransport)
+	grpcObs := runScenario(t, newGRPCTransport)
+
+	if len(rest) != len(grpcObs) {
+		t.Fatalf("scenario lengths differ: %d vs %d", len(rest), len(grpcObs))
+	}
+	for i := range rest {
+		r, g := rest[i], grpcObs[i]
+		if !reflect.DeepEqual(r.Err, g.Err) {
+			t.Errorf("%s: REST error %+v, gRPC error %+v", r.Step, r.Err, g.Err)
+		}
+		if !equalMessage(r.Value, g.Value) {
+			t.Errorf("%s: REST returned %v, gRPC returned %v", r.Step, r.Value, g.Value)
+		}
+		if len(r.Tasks) != len(g.Tasks) {
+			t.Errorf("%s: REST listed %d tasks, gRPC listed %d", r.Step, len(r.Tasks), len(g.Tasks))
+			continue
+		}
+		for j := range r.Tasks {
+			if !equalMessage(r.Tasks[j], g.Tasks[j]) {
+				t.Errorf("%s: task %d differs: REST %v, gRPC %v", r.Step, j, r.Tasks[j], g.Tasks[j])
+			}
+		}
+	}
+
+	// 场景本身需覆盖成功和失败的情况，以及评论数和检查项进度
+	if task, _ := rest[6].Value.(*taskv1.Task); task.GetCommentCount() != 1 || task.GetProgress() != 100 {
+		t.Errorf("expected the completed task to carry its comment count and progress, got %v", task)
+	}
+	if rest[3].Err == nil || rest[3].Err.Code != 1001 || rest[3].Err.Fields["title"] == "" {
+		t.Errorf("expected a validation error for an empty title, got %+v", rest[3].Err)
+	}
+	if abort := rest[10]; abort.Err == nil || abort.Err.Code != 1003 || abort.Value == nil {
+		t.Errorf("expected an aborted batch with per-item results, got %+v", abort)
+	}
+}
+
+// equalMessage 比较两个消息，忽略创建和更新时间
+func equalMessage(a, b proto.Message) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) == isNil(b)
+	}
+	a, b = proto.Clone(a), proto.Clone(b)
+	for _, m := range []proto.Message{a, b} {
+		if task, ok := m.(*taskv1.Task); ok {
+			task.CreatedAt, task.UpdatedAt = "", ""
+		}
+	}
+	return proto.Equal(a, b)
+}
+
+// isNil 判断接口中的消息是否为空
+func isNil(m proto.Message) bool {
+	return m == nil || reflect.ValueOf(m).IsNil()
+}
+
+// restTransport 通过 gin 路由调用 HTTP 接口
+type restTransport struct {
+	t   *testing.T
+	srv *httptest.Server
+}
+
+func newRESTTransport(t *testing.T) transport {
+	srv := httptest.NewServer(routes.SetupRouter())
+	t.Cleanup(srv.Close)
+	return &restTransport{t: t, srv: srv}
+}
+
+// do 发送请求并将响应中的 data 解码到 data，失败时返回错误码
+func (r *restTransport) do(method, path string, body interface{}, data interface{}) *callError {
+	var reader io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		reader = bytes.NewReader(b)
+	}
+	req, _ := http.NewRequest(method, r.srv.URL+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		r.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Code   int               `json:"code"`
+		Data   json.RawMessage   `json:"data"`
+		Fields map[string]string `json:"fields"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		r.t.Fatalf("%s %s: invalid response: %v", method, path, err)
+	}
+	if data != nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+		if err = json.Unmarshal(envelope.Data, data); err != nil {
+			r.t.Fatalf("%s %s: invalid data: %v", method, path, err)
+		}
+	}
+	if envelope.Code != 0 {
+		return &callError{Code: envelope.Code, Fields: envelope.Fields}
+	}
+	return nil
+}
+
+func (r *restTransport) create(req dto.CreateTaskReq) (*taskv1.Task, *callError) {
+	var task dto.TaskDTO
+	if err := r.do(http.MethodPost, "/api/v1/tasks", req, &task); err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (r *restTransport) list(status string) ([]*taskv1.Task, *callError) {
+	var resp dto.FetchAllTasksResp
+	if err := r.do(http.MethodGet, "/api/v1/tasks?status="+status, nil, &resp); err != nil {
+		return nil, err
+	}
+	var tasks []*taskv1.Task
+	for _, task := range resp.Tasks {
+		tasks = append(tasks, toTask(task))
+	}
+	return tasks, nil
+}
+
+func (r *restTransport) complete(id uint64) (*taskv1.Task, *callError) {
+	path := fmt.Sprintf("/api/v1/tasks/%d", id)
+	if err := r.do(http.MethodPatch, path+"/complete", nil, nil); err != nil {
+		return nil, err
+	}
+	var task dto.TaskDTO
+	if err := r.do(http.MethodGet, path, nil, &task); err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (r *restTransport) batchComplete(ids []uint64, atomic bool) (*taskv1.BatchTaskActionResponse, *callError) {
+	req := dto.BatchTaskActionReq{Atomic: atomic}
+	for _, id := range ids {
+		req.IDs = append(req.IDs, uint(id))
+	}
+	var resp *dto.BatchTaskActionResp
+	err := r.do(http.MethodPatch, "/api/v1/tasks/batch/complete", req, &resp)
+	if resp == nil {
+		return nil, err
+	}
+	return toBatchResponse(*resp), err
+}
+
+// grpcTransport 通过 bufconn 调用 gRPC 服务
+type grpcTransport struct {
+	client taskv1.TaskServiceClient
+}
+
+func newGRPCTransport(t *testing.T) transport {
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer()
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &grpcTransport{client: taskv1.NewTaskServiceClient(conn)}
+}
+
+// grpcError 从 gRPC 状态中取出错误码、字段级错误和批量操作结果
+func grpcError(err error) (*callError, *taskv1.BatchTaskActionResponse) {
+	if err == nil {
+		return nil, nil
+	}
+	callErr := &callError{}
+	var batch *taskv1.BatchTaskActionResponse
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			callErr.Code, _ = strconv.Atoi(d.Metadata["code"])
+		case *errdetails.BadRequest:
+			callErr.Fields = map[string]string{}
+			for _, v := range d.FieldViolations {
+				callErr.Fields[v.Field] = v.Description
+			}
+		case *taskv1.BatchTaskActionResponse:
+			batch = d
+		}
+	}
+	return callErr, batch
+}
+
+func (g *grpcTransport) create(req dto.CreateTaskReq) (*taskv1.Task, *callError) {
+	resp, err := g.client.CreateTask(context.Background(), &taskv1.CreateTaskRequest{
+		Title: req.Title, Description: req.Description, Category: req.Category, Color: req.Color, DueDate: req.DueDate,
+	})
+	if callErr, _ := grpcError(err); callErr != nil {
+		return nil, callErr
+	}
+	return resp.GetTask(), nil
+}
+
+func (g *grpcTransport) list(status string) ([]*taskv1.Task, *callError) {
+	stream, err := g.client.ListTasks(context.Background(), &taskv1.ListTasksRequest{Page: 1, Status: status})
+	if callErr, _ := grpcError(err); callErr != nil {
+		return nil, callErr
+	}
+	var tasks []*taskv1.Task
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return tasks, nil
+		}
+		if callErr, _ := grpcError(err); callErr != nil {
+			return nil, callErr
+		}
+		tasks = append(tasks, resp.GetTask())
+	}
+}
+
+func (g *grpcTransport) complete(id uint64) (*taskv1.Task, *callError) {
+	resp, err := g.client.CompleteTask(context.Background(), &taskv1.CompleteTaskRequest{Id: id})
+	if callErr, _ := grpcError(err); callErr != nil {
+		return nil, callErr
+	}
+	return resp.GetTask(), nil
+}
+
+func (g *grpcTransport) batchComplete(ids []uint64, atomic bool) (*taskv1.BatchTaskActionResponse, *callError) {
+	resp, err := g.client.BatchCompleteTasks(context.Background(), &taskv1.BatchTaskActionRequest{Ids: ids, Atomic: atomic})
+	if callErr, batch := grpcError(err); callErr != nil {
+		return batch, callErr
+	}
+	return resp, nil
+}
+
+// TestListTasksByMember ListTasks 支持按负责人和关注者过滤，me 取自 x-user 元数据
+func TestListTasksByMember(t *testing.T) {
+	testdb.Open(t)
+	client := newGRPCTransport(t).(*grpcTransport).client
+	alice := services.WithCaller(context.Background(), "alice", "")
+	// remaining_days 默认为 0，只列出已到期的任务
+	for _, title := range []string{"mine", "watched", "other"} {
+		if _, err := services.CreateTask(alice, dto.CreateTaskReq{Title: title, DueDate: "2020-01-01T10:00Z"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := services.AddTaskMember(alice, 1, services.RoleAssignee, dto.AddTaskMemberReq{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.AddTaskMember(alice, 2, services.RoleWatcher, dto.AddTaskMemberReq{User: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(ctx context.Context, req *taskv1.ListTasksRequest) ([]uint64, *callError) {
+		stream, err := client.ListTasks(ctx, req)
+		if callErr, _ := grpcError(err); callErr != nil {
+			return nil, callErr
+		}
+		var ids []uint64
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return ids, nil
+			}
+			if callErr, _ := grpcError(err); callErr != nil {
+				return nil, callErr
+			}
+			ids = append(ids, resp.GetTask().GetId())
+		}
+	}
+
+	asAlice := metadata.AppendToOutgoingContext(context.Background(), userMetadata, "alice")
+	if ids, err := list(asAlice, &taskv1.ListTasksRequest{Assignee: "me"}); err != nil || !reflect.DeepEqual(ids, []uint64{1}) {
+		t.Errorf("assignee=me: got %v, %+v", ids, err)
+	}
+	if ids, err := list(context.Background(), &taskv1.ListTasksRequest{Watcher: "bob"}); err != nil || !reflect.DeepEqual(ids, []uint64{2}) {
+		t.Errorf("watcher=bob: got %v, %+v", ids, err)
+	}
+	if _, err := list(context.Background(), &taskv1.ListTasksRequest{Assignee: "me"}); err == nil || err.Fields["assignee"] == "" {
+		t.Errorf("assignee=me without x-user: expected a validation error, got %+v", err)
+	}
+}
+
+// TestTaskMessageMatchesDTO TaskDTO 的每个字段都有对应的 protobuf 字段且由 toTask 转换，新增字段时需同步修改 proto
+func TestTaskMessageMatchesDTO(t *testing.T) {
+	var task dto.TaskDTO
+	v := reflect.ValueOf(&task).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("value " + strconv.Itoa(i))
+		case reflect.Int, reflect.Uint:
+			setNumber(field, int64(i+1))
+		case reflect.Ptr:
+			field.Set(reflect.New(field.Type().Elem()))
+			setNumber(field.Elem(), int64(i+1))
+		default:
+			t.Fatalf("unsupported TaskDTO field kind %s", field.Kind())
+		}
+	}
+
+	message := toTask(task).ProtoReflect()
+	fields := message.Descriptor().Fields()
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			t.Errorf("TaskDTO.%s (%s) has no field in taskv1.Task", v.Type().Field(i).Name, name)
+			continue
+		}
+		want := fmt.Sprint(reflect.Indirect(v.Field(i)).Interface())
+		if got := fmt.Sprint(message.Get(fd).Interface()); !message.Has(fd) || got != want {
+			t.Errorf("taskv1.Task.%s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+// TestListTasksRequestMatchesFilters FetchAllTasksReq 的每个查询参数都有对应的 ListTasksRequest 字段
+func TestListTasksRequestMatchesFilters(t *testing.T) {
+	fields := (&taskv1.ListTasksRequest{}).ProtoReflect().Descriptor().Fields()
+	typ := reflect.TypeOf(dto.FetchAllTasksReq{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Tag.Get("form")
+		if fields.ByName(protoreflect.Name(name)) == nil {
+			t.Errorf("FetchAllTasksReq.%s (%s) has no field in ListTasksRequest", typ.Field(i).Name, name)
+		}
+	}
+}
+
+// setNumber 设置整数或无符号整数字段
+func setNumber(v reflect.Value, n int64) {
+	if v.Kind() == reflect.Uint {
+		v.SetUint(uint64(n))
+		return
+	}
+	v.SetInt(n)
+}
+
+// TestEveryCodeHasGRPCStatus 错误码目录中的每个错误码都有对应的 gRPC 状态码
+func TestEveryCodeHasGRPCStatus(t *testing.T) {
+	for _, code := range apperrors.Codes {
+		if _, ok := grpcCodes[code]; !ok {
+			t.Errorf("error code %d (%s) has no gRPC status", code.Num, code.Name)
+		}
+	}
+}
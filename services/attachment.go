@@ -0,0 +1,301 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"E-Todo/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrAttachmentNotFound 附件不存在
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentTooLarge 附件超过大小上限
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+)
+
+const (
+	blobCleanupBatchSize = 100             // 每次删除的最大内容数
+	blobCleanupRetryBase = time.Minute     // 首次重试的等待时间，之后每次翻倍
+	blobCleanupRetryMax  = 6 * time.Hour   // 重试等待时间的上限
+	blobCleanupErrorSize = 1000            // 保存的错误信息最大长度
+	blobRequestTimeout   = 5 * time.Minute // 单次存储请求的超时时间
+)
+
+func init() {
+	RegisterTaskExpander("attachments", func(task models.Task) (interface{}, error) {
+		resp, err := fetchAttachments(config.DB, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Attachments, nil
+	})
+}
+
+// AttachmentContent 附件元数据及内容，调用方负责关闭 Body
+type AttachmentContent struct {
+	Attachment dto.AttachmentDTO
+	Body       io.ReadCloser
+}
+
+// UploadAttachment 上传任务附件，上传者为当前调用方
+// 内容先写入存储，再在事务中保存记录；保存失败时删除已写入的内容
+func UploadAttachment(ctx context.Context, taskID uint, file *multipart.FileHeader) (dto.AttachmentDTO, error) {
+	if max := config.AttachmentMaxSize(); file.Size > max {
+		return dto.AttachmentDTO{}, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrAttachmentTooLarge, file.Size, max)
+	}
+	if err := findActiveTask(config.DB.WithContext(ctx), taskID); err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+
+	name := filepath.Base(file.Filename)
+	if name == "." || name == string(filepath.Separator) || len(name) > 255 {
+		return dto.AttachmentDTO{}, FieldErrors{"file": "invalid file name"}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return dto.AttachmentDTO{}, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	// 未声明类型时根据内容的前 512 字节识别
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return dto.AttachmentDTO{}, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	head = head[:n]
+	mimeType := file.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err != nil || mediaType == "application/octet-stream" {
+		mimeType = http.DetectContentType(head)
+	}
+
+	key, err := newBlobKey(taskID)
+	if err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), src), hash)
+	if err = config.Blobs.Put(ctx, key, body, file.Size, mimeType); err != nil {
+		return dto.AttachmentDTO{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := models.Attachment{
+		TaskID:   taskID,
+		Name:     name,
+		Size:     file.Size,
+		MimeType: mimeType,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+		BlobKey:  key,
+		Uploader: models.ChangeContextFrom(ctx).Actor,
+	}
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定任务，避免任务同时被硬删除而遗留附件
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		if err := attachment.Create(tx); err != nil {
+			return fmt.Errorf("failed to create attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		discardBlob(key)
+		return dto.AttachmentDTO{}, err
+	}
+	return toAttachmentDTO(attachment), nil
+}
+
+// FetchAttachments 获取任务的附件，任务在回收站中时不可见
+func FetchAttachments(taskID uint) (dto.FetchAttachmentsResp, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return dto.FetchAttachmentsResp{}, err
+	}
+	return fetchAttachments(config.DB, taskID)
+}
+
+// GetAttachment 获取附件元数据
+func GetAttachment(taskID, id uint) (dto.AttachmentDTO, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+	attachment, err := findAttachment(config.DB, taskID, id)
+	if err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+	return toAttachmentDTO(attachment), nil
+}
+
+// OpenAttachment 打开附件内容用于下载
+func OpenAttachment(ctx context.Context, taskID, id uint) (AttachmentContent, error) {
+	db := config.DB.WithContext(ctx)
+	if err := findActiveTask(db, taskID); err != nil {
+		return AttachmentContent{}, err
+	}
+	attachment, err := findAttachment(db, taskID, id)
+	if err != nil {
+		return AttachmentContent{}, err
+	}
+	body, err := config.Blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return AttachmentContent{}, fmt.Errorf("%w: content of attachment %d is missing", ErrAttachmentNotFound, id)
+		}
+		return AttachmentContent{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return AttachmentContent{Attachment: toAttachmentDTO(attachment), Body: body}, nil
+}
+
+// DeleteAttachment 删除附件，内容由后台任务从存储中删除
+func DeleteAttachment(ctx context.Context, taskID, id uint) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		attachment, err := findAttachment(tx, taskID, id)
+		if err != nil {
+			return err
+		}
+		if err = attachment.Delete(tx); err != nil {
+			return fmt.Errorf("failed to delete attachment: %w", err)
+		}
+		return nil
+	})
+}
+
+// StartBlobCleanup 启动后台任务，按 interval 周期从存储中删除已删除附件的内容，失败时按退避时间重试
+// 待删除的内容与附件记录的删除在同一事务中写入，硬删除任务的事务回滚时内容不会被删除
+func StartBlobCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := cleanupBlobs(); err != nil {
+				log.Printf("Blob cleanup: %v", err)
+			}
+		}
+	}()
+}
+
+// cleanupBlobs 删除一批到期的内容
+func cleanupBlobs() error {
+	var model models.BlobDeletion
+	deletions, err := model.FetchDue(config.DB, time.Now(), blobCleanupBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch blob deletions: %w", err)
+	}
+
+	for i := range deletions {
+		deletion := deletions[i]
+		ctx, cancel := context.WithTimeout(context.Background(), blobRequestTimeout)
+		err = config.Blobs.Delete(ctx, deletion.BlobKey)
+		cancel()
+		if err != nil {
+			msg := err.Error()
+			if len(msg) > blobCleanupErrorSize {
+				msg = msg[:blobCleanupErrorSize]
+			}
+			retryAt := time.Now().Add(blobCleanupBackoff(deletion.Attempts + 1))
+			if markErr := deletion.MarkFailed(config.DB, msg, retryAt); markErr != nil {
+				return fmt.Errorf("failed to record failure for blob %s: %w", deletion.BlobKey, markErr)
+			}
+			log.Printf("Blob cleanup: %s: %v", deletion.BlobKey, err)
+			continue
+		}
+		if err = deletion.Done(config.DB); err != nil {
+			return fmt.Errorf("failed to remove blob deletion %d: %w", deletion.ID, err)
+		}
+	}
+	return nil
+}
+
+// blobCleanupBackoff 第 attempt 次删除失败后的重试等待时间
+func blobCleanupBackoff(attempt int) time.Duration {
+	backoff := blobCleanupRetryBase
+	for i := 1; i < attempt && backoff < blobCleanupRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > blobCleanupRetryMax {
+		backoff = blobCleanupRetryMax
+	}
+	return backoff
+}
+
+// discardBlob 删除未能保存记录的上传内容，失败时交由后台任务重试
+func discardBlob(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), blobRequestTimeout)
+	defer cancel()
+	if err := config.Blobs.Delete(ctx, key); err != nil {
+		deletion := models.BlobDeletion{BlobKey: key}
+		if err = deletion.Create(config.DB); err != nil {
+			log.Printf("Failed to discard blob %s: %v", key, err)
+		}
+	}
+}
+
+// newBlobKey 生成附件内容的 key，按任务分目录，文件名随机生成，与上传的文件名无关
+func newBlobKey(taskID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate blob key: %w", err)
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// findAttachment 查询任务下的附件，调用方需先确认任务不在回收站中
+func findAttachment(db *gorm.DB, taskID, id uint) (models.Attachment, error) {
+	var attachment models.Attachment
+	if err := attachment.FetchByID(db, taskID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Attachment{}, fmt.Errorf("%w: %d", ErrAttachmentNotFound, id)
+		}
+		return models.Attachment{}, fmt.Errorf("failed to find attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// fetchAttachments 查询任务的全部附件
+func fetchAttachments(db *gorm.DB, taskID uint) (dto.FetchAttachmentsResp, error) {
+	var model models.Attachment
+	attachments, err := model.FetchByTask(db, taskID)
+	if err != nil {
+		return dto.FetchAttachmentsResp{}, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	resp := dto.FetchAttachmentsResp{Attachments: make([]dto.AttachmentDTO, 0, len(attachments))}
+	for _, attachment := range attachments {
+		resp.Attachments = append(resp.Attachments, toAttachmentDTO(attachment))
+	}
+	return resp, nil
+}
+
+// toAttachmentDTO 将附件模型转换为 AttachmentDTO
+func toAttachmentDTO(a models.Attachment) dto.AttachmentDTO {
+	return dto.AttachmentDTO{
+		ID:        a.ID,
+		TaskID:    a.TaskID,
+		Name:      a.Name,
+		Size:      a.Size,
+		MimeType:  a.MimeType,
+		Checksum:  a.Checksum,
+		Uploader:  a.Uploader,
+		CreatedAt: a.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"E-Todo/storage"
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"testing"
+	"time"
+)
+
+// setupBlobs 使用临时目录作为附件存储
+func setupBlobs(t *testing.T) {
+	testdb.Open(t)
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := config.Blobs
+	config.Blobs = store
+	t.Cleanup(func() { config.Blobs = previous })
+}
+
+// createTaskWithAttachment 创建带一个附件的任务
+func createTaskWithAttachment(t *testing.T, ctx context.Context) (dto.TaskDTO, dto.AttachmentDTO) {
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("file", "notes.txt")
+	_, _ = part.Write([]byte("attachment content"))
+	_ = w.Close()
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = form.RemoveAll() })
+
+	attachment, err := UploadAttachment(ctx, task.ID, form.File["file"][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task, attachment
+}
+
+// pendingBlobDeletions 查询待删除队列中的内容
+func pendingBlobDeletions(t *testing.T) []string {
+	var keys []string
+	if err := config.DB.Model(&models.BlobDeletion{}).Order("id").Pluck("blob_key", &keys).Error; err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// attachmentBlobKey 查询附件内容的 key
+func attachmentBlobKey(t *testing.T, id uint) string {
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return attachment.BlobKey
+}
+
+// TestHardDeleteEnqueuesBlobs 软删除任务保留附件内容，硬删除时加入待删除队列并由后台任务删除
+func TestHardDeleteEnqueuesBlobs(t *testing.T) {
+	setupBlobs(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	task, attachment := createTaskWithAttachment(t, ctx)
+	key := attachmentBlobKey(t, attachment.ID)
+
+	if err := SoftDelete(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if keys := pendingBlobDeletions(t); len(keys) != 0 {
+		t.Fatalf("soft delete enqueued %v", keys)
+	}
+	if err := RestoreTask(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteTask(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if keys := pendingBlobDeletions(t); len(keys) != 1 || keys[0] != key {
+		t.Fatalf("hard delete enqueued %v, want [%s]", keys, key)
+	}
+
+	if err := cleanupBlobs(); err != nil {
+		t.Fatal(err)
+	}
+	if keys := pendingBlobDeletions(t); len(keys) != 0 {
+		t.Errorf("blob deletions left after cleanup: %v", keys)
+	}
+	if _, err := config.Blobs.Get(context.Background(), key); !errors.Is(err, storage.ErrBlobNotFound) {
+		t.Errorf("blob still stored after cleanup: %v", err)
+	}
+}
+
+// TestEmptyTrashEnqueuesBlobs 清空回收站时回收站中任务的附件内容加入待删除队列，其他任务不受影响
+func TestEmptyTrashEnqueuesBlobs(t *testing.T) {
+	setupBlobs(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	trashed, attachment := createTaskWithAttachment(t, ctx)
+	createTaskWithAttachment(t, ctx)
+	key := attachmentBlobKey(t, attachment.ID)
+
+	if err := SoftDelete(ctx, trashed.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := EmptyTrash(ctx); err != nil || purged != 1 {
+		t.Fatalf("empty trash: %d %v", purged, err)
+	}
+	if keys := pendingBlobDeletions(t); len(keys) != 1 || keys[0] != key {
+		t.Fatalf("empty trash enqueued %v, want [%s]", keys, key)
+	}
+}
+
+// TestPurgeExpiredTrashEnqueuesBlobs 自动清理超过保留天数的任务时附件内容加入待删除队列，未过期的任务保留
+func TestPurgeExpiredTrashEnqueuesBlobs(t *testing.T) {
+	setupBlobs(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	expired, attachment := createTaskWithAttachment(t, ctx)
+	recent, _ := createTaskWithAttachment(t, ctx)
+	key := attachmentBlobKey(t, attachment.ID)
+
+	for _, id := range []uint{expired.ID, recent.ID} {
+		if err := SoftDelete(ctx, id, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := config.DB.Unscoped().Model(&models.Task{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -31)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := PurgeExpiredTrash(30); err != nil || purged != 1 {
+		t.Fatalf("purge: %d %v", purged, err)
+	}
+	if keys := pendingBlobDeletions(t); len(keys) != 1 || keys[0] != key {
+		t.Fatalf("purge enqueued %v, want [%s]", keys, key)
+	}
+}
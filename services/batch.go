@@ -0,0 +1,188 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// ExecuteBatch 在一个事务中按顺序执行一组混合操作，全部操作记录为一次 task.batch 操作，可一并撤销
+// 任一操作失败时整批回滚，失败操作之后的操作标记为 skipped
+func ExecuteBatch(ctx context.Context, req dto.BatchOperationsReq) (dto.BatchOperationsResp, error) {
+	resp := dto.BatchOperationsResp{
+		Results: make([]dto.BatchOperationResult, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		resp.Results[i] = dto.BatchOperationResult{Index: i, Op: op.Op, ID: op.ID, Ref: op.Ref, Status: models.BatchOpStatusSkipped}
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return models.RecordAsOneOperation(tx, models.TaskActionBatch, func(tx *gorm.DB) error {
+			// 同批次内 create 操作创建的任务，ref -> 任务 ID
+			refs := make(map[string]uint)
+
+			for i, op := range req.Operations {
+				result := &resp.Results[i]
+
+				task, err := executeBatchOperation(tx, op, refs)
+				if err != nil {
+					result.Status = models.BatchOpStatusError
+					result.Error = err.Error()
+					return fmt.Errorf("operation %d (%s): %w", i, op.Op, models.ErrBatchAborted)
+				}
+
+				result.Status = models.BatchOpStatusOK
+				if task != nil {
+					taskDTO := toTaskDTO(*task)
+					result.ID = task.ID
+					result.Task = &taskDTO
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Committed = true
+	return resp, nil
+}
+
+// executeBatchOperation 在事务中执行单个操作，返回操作后的任务（删除类操作返回 nil）
+func executeBatchOperation(tx *gorm.DB, op dto.BatchOperation, refs map[string]uint) (*models.Task, error) {
+	if op.Op == models.BatchOpCreate {
+		var req dto.CreateTaskReq
+		if err := decodeBatchData(op.Data, &req); err != nil {
+			return nil, err
+		}
+		if op.Ref != "" {
+			if _, ok := refs[op.Ref]; ok {
+				return nil, fmt.Errorf("duplicate ref %q", op.Ref)
+			}
+		}
+
+		task, err := createTask(tx, req)
+		if err != nil {
+			return nil, err
+		}
+		if op.Ref != "" {
+			refs[op.Ref] = task.ID
+		}
+		return &task, nil
+	}
+
+	id, err := resolveBatchTarget(op, refs)
+	if err != nil {
+		return nil, err
+	}
+	task := models.Task{ID: id, Version: op.Version}
+
+	switch op.Op {
+	case models.BatchOpUpdate:
+		var req dto.UpdateTaskReq
+		if err = unmarshalBatchData(op.Data, &req); err != nil {
+			return nil, err
+		}
+		// 目标任务以 id/ref 为准，忽略 data 中的 id，须在校验前设置以满足 ID 的必填规则
+		req.ID = id
+		if op.Version != 0 {
+			req.Version = op.Version
+		}
+		if err = validateBatchData(&req); err != nil {
+			return nil, err
+		}
+		task, err = updateTask(tx, req)
+	case models.BatchOpMoveCategory:
+		task, err = moveTaskCategory(tx, id, op.Version, op.Category)
+	case models.BatchOpComplete:
+		err = task.Complete(tx)
+	case models.BatchOpSoftDelete:
+		if err = task.SoftDelete(tx); err == nil {
+			return nil, nil
+		}
+	case models.BatchOpRestore:
+		err = task.Restore(tx)
+	case models.BatchOpDelete:
+		if err = task.Delete(tx); err == nil {
+			return nil, nil
+		}
+	default:
+		err = fmt.Errorf("unsupported operation %q", op.Op)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// resolveBatchTarget 解析操作的目标任务 ID，ref 优先引用同批次内创建的任务
+func resolveBatchTarget(op dto.BatchOperation, refs map[string]uint) (uint, error) {
+	if op.Ref != "" {
+		id, ok := refs[op.Ref]
+		if !ok {
+			return 0, fmt.Errorf("unknown ref %q: no task created with this ref earlier in the batch", op.Ref)
+		}
+		return id, nil
+	}
+	if op.ID == 0 {
+		return 0, errors.New("either id or ref is required")
+	}
+	return op.ID, nil
+}
+
+// decodeBatchData 解析并校验操作中的 data 字段
+func decodeBatchData(data json.RawMessage, obj interface{}) error {
+	if err := unmarshalBatchData(data, obj); err != nil {
+		return err
+	}
+	return validateBatchData(obj)
+}
+
+// unmarshalBatchData 解析操作中的 data 字段，不做校验
+func unmarshalBatchData(data json.RawMessage, obj interface{}) error {
+	if len(data) == 0 {
+		return errors.New("data is required")
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	return nil
+}
+
+// validateBatchData 按 binding 标签校验解析后的 data
+func validateBatchData(obj interface{}) error {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	return nil
+}
+
+// moveTaskCategory 将任务移动到指定分类，category 为空表示移出分类
+func moveTaskCategory(tx *gorm.DB, id uint, version uint, category string) (models.Task, error) {
+	var task models.Task
+
+	// 查询任务
+	if err := tx.Where("id = ?", id).First(&task).Error; err != nil {
+		return models.Task{}, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	// 校验期望版本
+	if version != 0 && version != task.Version {
+		return models.Task{}, &models.VersionConflictError{Current: task}
+	}
+
+	task.Category = category
+	if err := task.Update(tx); err != nil {
+		return models.Task{}, fmt.Errorf("failed to move task: %w", err)
+	}
+
+	return task, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// TestBatchUpdateWithoutDataID update 操作的目标由 id/ref 决定，data 中无需也不受 id 影响
+func TestBatchUpdateWithoutDataID(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	existing, err := CreateTask(ctx, dto.CreateTaskReq{Title: "existing", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ExecuteBatch(ctx, dto.BatchOperationsReq{Operations: []dto.BatchOperation{
+		{Op: models.BatchOpCreate, Ref: "new", Data: json.RawMessage(`{"title":"new","due_date":"2030-01-01T00:00Z"}`)},
+		{Op: models.BatchOpUpdate, Ref: "new", Data: json.RawMessage(`{"title":"renamed"}`)},
+		{Op: models.BatchOpUpdate, ID: existing.ID, Data: json.RawMessage(`{"id":0,"title":"updated"}`)},
+	}})
+	if err != nil || !resp.Committed {
+		t.Fatalf("batch: %+v %v", resp, err)
+	}
+	for i, want := range map[int]string{1: "renamed", 2: "updated"} {
+		result := resp.Results[i]
+		if result.Status != models.BatchOpStatusOK || result.Task == nil || result.Task.Title != want {
+			t.Errorf("result %d: %+v, want title %q", i, result, want)
+		}
+	}
+	if resp.Results[1].ID != resp.Results[0].ID || resp.Results[2].ID != existing.ID {
+		t.Errorf("updated wrong tasks: %+v", resp.Results)
+	}
+}
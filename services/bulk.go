@@ -0,0 +1,146 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// defaultBulkPreviewLimit 批量更新默认预览条数
+const defaultBulkPreviewLimit = 20
+
+// ErrInvalidBulkChanges 批量更新的字段变更不合法
+var ErrInvalidBulkChanges = errors.New("invalid bulk update changes")
+
+// BulkUpdateTasks 按条件批量更新任务字段，dry_run 时只返回受影响数量和变更预览
+func BulkUpdateTasks(ctx context.Context, req dto.BulkUpdateTasksReq) (dto.BulkUpdateTasksResp, error) {
+	apply, err := bulkUpdateApplier(req.Changes)
+	if err != nil {
+		return dto.BulkUpdateTasksResp{}, err
+	}
+
+	params := models.TaskQueryParams{
+		KeyWords:      req.Filter.KeyWords,
+		Category:      req.Filter.Category,
+		Status:        req.Filter.Status,
+		Color:         req.Filter.Color,
+		RemainingDays: -1,
+	}
+	if req.Filter.RemainingDays != nil {
+		params.RemainingDays = *req.Filter.RemainingDays
+	}
+
+	previewLimit := req.PreviewLimit
+	if previewLimit <= 0 {
+		previewLimit = defaultBulkPreviewLimit
+	}
+
+	resp := dto.BulkUpdateTasksResp{
+		DryRun:  req.DryRun,
+		Preview: []dto.BulkUpdatePreview{},
+	}
+
+	// 全部任务的更新记录为一次操作，可一并撤销
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return models.RecordAsOneOperation(tx, models.TaskEventUpdated, func(tx *gorm.DB) error {
+			return bulkUpdate(tx, params, req.DryRun, previewLimit, apply, &resp)
+		})
+	})
+	if err != nil {
+		return dto.BulkUpdateTasksResp{}, fmt.Errorf("failed to bulk update tasks: %w", err)
+	}
+
+	return resp, nil
+}
+
+// bulkUpdate 在事务中更新符合条件的任务，并将受影响数量和预览写入 resp
+// 试运行时只读取不加锁；符合条件的任务超过 BULK_UPDATE_MAX_TASKS 时拒绝，避免一次锁定过多任务
+func bulkUpdate(tx *gorm.DB, params models.TaskQueryParams, dryRun bool, previewLimit int, apply func(t *models.Task), resp *dto.BulkUpdateTasksResp) error {
+	var task models.Task
+	find := task.FindForUpdate
+	if dryRun {
+		find = task.FindMatching
+	}
+	maxTasks := config.BulkUpdateMaxTasks()
+	tasks, err := find(tx, params, maxTasks+1)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	if len(tasks) > maxTasks {
+		return FieldErrors{"filter": fmt.Sprintf("matches more than %d tasks, narrow the filter", maxTasks)}
+	}
+	resp.Matched = len(tasks)
+
+	for _, t := range tasks {
+		original := t
+		apply(&t)
+		// 变更后与原值相同的任务不更新，避免无意义地增加版本号和记录变更
+		if !bulkChanged(original, t) {
+			continue
+		}
+
+		before := toTaskDTO(original)
+		if len(resp.Preview) < previewLimit {
+			resp.Preview = append(resp.Preview, dto.BulkUpdatePreview{Before: before, After: toTaskDTO(t)})
+		}
+		if dryRun {
+			continue
+		}
+
+		if err = t.Update(tx); err != nil {
+			return fmt.Errorf("failed to update task with ID %d: %w", t.ID, err)
+		}
+		resp.Affected++
+	}
+	return nil
+}
+
+// bulkChanged 判断批量更新是否修改了任务的字段
+func bulkChanged(before, after models.Task) bool {
+	return before.Category != after.Category || before.Color != after.Color || before.Status != after.Status ||
+		!before.DueDate.Equal(after.DueDate)
+}
+
+// bulkUpdateApplier 校验字段变更并返回将变更应用到任务上的函数
+func bulkUpdateApplier(changes dto.BulkUpdateChanges) (func(t *models.Task), error) {
+	shift := time.Duration(changes.ShiftDueDateDays)*24*time.Hour + time.Duration(changes.ShiftDueDateHours)*time.Hour
+
+	if changes.Category == nil && changes.Color == nil && changes.Status == nil && changes.DueDate == nil && shift == 0 {
+		return nil, fmt.Errorf("%w: no changes provided", ErrInvalidBulkChanges)
+	}
+	if changes.DueDate != nil && shift != 0 {
+		return nil, fmt.Errorf("%w: due_date and due date shift cannot be used together", ErrInvalidBulkChanges)
+	}
+
+	var dueDate time.Time
+	if changes.DueDate != nil {
+		var err error
+		dueDate, err = time.Parse("2006-01-02T15:04Z", *changes.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid due date format: %v", ErrInvalidBulkChanges, err)
+		}
+	}
+
+	return func(t *models.Task) {
+		if changes.Category != nil {
+			t.Category = *changes.Category
+		}
+		if changes.Color != nil {
+			t.Color = *changes.Color
+		}
+		if changes.Status != nil {
+			t.Status = *changes.Status
+		}
+		if changes.DueDate != nil {
+			t.DueDate = dueDate
+		}
+		if shift != 0 {
+			t.DueDate = t.DueDate.Add(shift)
+		}
+	}, nil
+}
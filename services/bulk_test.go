@@ -0,0 +1,79 @@
+package services
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"context"
+	"testing"
+)
+
+// TestBulkUpdateSkipsUnchangedTasks 变更后与原值相同的任务不更新，不增加版本号也不计入受影响数量
+func TestBulkUpdateSkipsUnchangedTasks(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	unchanged, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", Category: "home", Color: "red", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := CreateTask(ctx, dto.CreateTaskReq{Title: "b", Category: "home", Color: "blue", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	red := "red"
+	resp, err := BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{Category: "home"}, Changes: dto.BulkUpdateChanges{Color: &red}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Matched != 2 || resp.Affected != 1 || len(resp.Preview) != 1 || resp.Preview[0].Before.ID != changed.ID {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	for _, want := range []dto.TaskDTO{unchanged, changed} {
+		task, err := GetTask(want.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantVersion := want.Version
+		if want.ID == changed.ID {
+			wantVersion++
+		}
+		if task.Version != wantVersion || task.Color != "red" {
+			t.Errorf("task %d: version %d color %s, want version %d color red", task.ID, task.Version, task.Color, wantVersion)
+		}
+	}
+
+	// 没有任务需要修改时不记录操作
+	before := latestOperation(t)
+	if resp, err = BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{Category: "home"}, Changes: dto.BulkUpdateChanges{Color: &red}}); err != nil || resp.Affected != 0 {
+		t.Fatalf("no-op bulk update: %+v %v", resp, err)
+	}
+	if latest := latestOperation(t); latest.ID != before.ID {
+		t.Errorf("no-op bulk update recorded operation %+v", latest)
+	}
+}
+
+// TestBulkUpdateMaxTasks 符合条件的任务超过上限时拒绝请求，试运行同样受限
+func TestBulkUpdateMaxTasks(t *testing.T) {
+	testdb.Open(t)
+	t.Setenv("BULK_UPDATE_MAX_TASKS", "1")
+	ctx := WithCaller(context.Background(), "alice", "")
+	for _, title := range []string{"a", "b"} {
+		if _, err := CreateTask(ctx, dto.CreateTaskReq{Title: title, Category: "home", DueDate: "2030-01-01T00:00Z"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	red := "red"
+	for _, dryRun := range []bool{true, false} {
+		_, err := BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{Category: "home"}, Changes: dto.BulkUpdateChanges{Color: &red}, DryRun: dryRun})
+		if appErr := apperrors.From(err); appErr.Code != apperrors.CodeValidation || appErr.Fields["filter"] == "" {
+			t.Errorf("dry run %v: expected a filter validation error, got %v", dryRun, err)
+		}
+	}
+
+	resp, err := BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{KeyWords: "a"}, Changes: dto.BulkUpdateChanges{Color: &red}})
+	if err != nil || resp.Affected != 1 {
+		t.Errorf("bulk update within the limit: %+v %v", resp, err)
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ErrChecklistItemNotFound 检查项不存在
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+func init() {
+	RegisterTaskExpander("checklist", func(task models.Task) (interface{}, error) {
+		return fetchChecklist(config.DB, task.ID)
+	})
+}
+
+// FetchChecklist 获取任务的检查清单，任务在回收站中时不可见
+func FetchChecklist(taskID uint) (dto.ChecklistDTO, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return dto.ChecklistDTO{}, err
+	}
+	return fetchChecklist(config.DB, taskID)
+}
+
+// AddChecklistItem 在任务的检查清单中添加一项，未指定位置时追加到末尾
+func AddChecklistItem(ctx context.Context, taskID uint, req dto.CreateChecklistItemReq) (dto.ChecklistItemDTO, error) {
+	item := models.ChecklistItem{TaskID: taskID, Text: req.Text, Checked: req.Checked, Position: -1}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定任务以保证序号和计数一致
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		if err := item.Create(tx); err != nil {
+			return fmt.Errorf("failed to create checklist item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ChecklistItemDTO{}, err
+	}
+	return toChecklistItemDTO(item), nil
+}
+
+// UpdateChecklistItem 修改检查项的内容或勾选状态
+func UpdateChecklistItem(ctx context.Context, taskID, id uint, req dto.UpdateChecklistItemReq) (dto.ChecklistItemDTO, error) {
+	if req.Text == nil && req.Checked == nil {
+		return dto.ChecklistItemDTO{}, FieldErrors{"text": "at least one of text and checked is required"}
+	}
+
+	var item models.ChecklistItem
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if item, err = findChecklistItem(tx, taskID, id); err != nil {
+			return err
+		}
+		if err = item.Update(tx, req.Text, req.Checked); err != nil {
+			return fmt.Errorf("failed to update checklist item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ChecklistItemDTO{}, err
+	}
+	return toChecklistItemDTO(item), nil
+}
+
+// ReorderChecklist 按给定顺序重新排列任务的检查项，需包含全部检查项且不重复
+func ReorderChecklist(ctx context.Context, taskID uint, req dto.ReorderChecklistReq) (dto.ChecklistDTO, error) {
+	var checklist dto.ChecklistDTO
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+
+		var model models.ChecklistItem
+		items, err := model.FetchByTask(tx, taskID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch checklist: %w", err)
+		}
+		remaining := make(map[uint]bool, len(items))
+		for _, item := range items {
+			remaining[item.ID] = true
+		}
+		for _, id := range req.ItemIDs {
+			if !remaining[id] {
+				return FieldErrors{"item_ids": fmt.Sprintf("item %d is not on this checklist or is listed twice", id)}
+			}
+			delete(remaining, id)
+		}
+		if len(remaining) > 0 {
+			return FieldErrors{"item_ids": "must list every item on the checklist"}
+		}
+
+		if err = model.Reorder(tx, taskID, req.ItemIDs); err != nil {
+			return fmt.Errorf("failed to reorder checklist: %w", err)
+		}
+		checklist, err = fetchChecklist(tx, taskID)
+		return err
+	})
+	if err != nil {
+		return dto.ChecklistDTO{}, err
+	}
+	return checklist, nil
+}
+
+// DeleteChecklistItem 删除检查项
+func DeleteChecklistItem(ctx context.Context, taskID, id uint) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		item, err := findChecklistItem(tx, taskID, id)
+		if err != nil {
+			return err
+		}
+		if err = item.Delete(tx); err != nil {
+			return fmt.Errorf("failed to delete checklist item: %w", err)
+		}
+		return nil
+	})
+}
+
+// findChecklistItem 锁定活动任务并查询其下的检查项，需在事务中调用
+func findChecklistItem(tx *gorm.DB, taskID, id uint) (models.ChecklistItem, error) {
+	if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+		return models.ChecklistItem{}, err
+	}
+
+	var item models.ChecklistItem
+	if err := item.FetchByID(tx, taskID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ChecklistItem{}, fmt.Errorf("%w: %d", ErrChecklistItemNotFound, id)
+		}
+		return models.ChecklistItem{}, fmt.Errorf("failed to find checklist item: %w", err)
+	}
+	return item, nil
+}
+
+// fetchChecklist 查询任务的全部检查项及完成进度
+func fetchChecklist(db *gorm.DB, taskID uint) (dto.ChecklistDTO, error) {
+	var model models.ChecklistItem
+	items, err := model.FetchByTask(db, taskID)
+	if err != nil {
+		return dto.ChecklistDTO{}, fmt.Errorf("failed to fetch checklist: %w", err)
+	}
+
+	checklist := dto.ChecklistDTO{Items: make([]dto.ChecklistItemDTO, 0, len(items)), Total: len(items)}
+	for _, item := range items {
+		checklist.Items = append(checklist.Items, toChecklistItemDTO(item))
+		if item.Checked {
+			checklist.Checked++
+		}
+	}
+	checklist.Progress = checklistProgress(checklist.Checked, checklist.Total)
+	return checklist, nil
+}
+
+// checklistProgress 检查项完成百分比，向下取整，没有检查项时为 nil
+func checklistProgress(checked, total int) *int {
+	if total == 0 {
+		return nil
+	}
+	progress := checked * 100 / total
+	return &progress
+}
+
+// toChecklistItemDTO 将检查项模型转换为 ChecklistItemDTO
+func toChecklistItemDTO(c models.ChecklistItem) dto.ChecklistItemDTO {
+	return dto.ChecklistItemDTO{
+		ID:        c.ID,
+		TaskID:    c.TaskID,
+		Text:      c.Text,
+		Checked:   c.Checked,
+		Position:  c.Position,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	// ErrCommentNotFound 评论不存在
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrCommentForbidden 只有评论的作者可以修改或删除评论
+	ErrCommentForbidden = errors.New("only the author can modify a comment")
+)
+
+// commentsExpandLimit 通过 ?expand=comments 返回的最大顶层评论数
+const commentsExpandLimit = 100
+
+func init() {
+	RegisterTaskExpander("comments", func(task models.Task) (interface{}, error) {
+		resp, err := fetchComments(config.DB, task.ID, dto.FetchCommentsReq{Page: 1, Limit: commentsExpandLimit})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Comments, nil
+	})
+}
+
+// CreateComment 在任务下发表评论或回复，作者为当前调用方
+func CreateComment(ctx context.Context, taskID uint, req dto.CreateCommentReq) (dto.CommentDTO, error) {
+	comment := models.Comment{
+		TaskID:   taskID,
+		ParentID: req.ParentID,
+		Author:   models.ChangeContextFrom(ctx).Actor,
+		Body:     req.Body,
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定任务以保证评论数一致
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+
+		// 只能回复同一任务下的评论
+		if req.ParentID != nil {
+			var parent models.Comment
+			if err := parent.FetchByID(tx, taskID, *req.ParentID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return FieldErrors{"parent_id": "comment does not exist on this task"}
+				}
+				return fmt.Errorf("failed to find parent comment: %w", err)
+			}
+		}
+
+		if err := comment.Create(tx); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.CommentDTO{}, err
+	}
+	return toCommentDTO(comment), nil
+}
+
+// FetchComments 分页获取任务的评论，按时间顺序排列，回复嵌套在所回复的评论下
+// 任务在回收站中时其评论不可见，恢复后重新可见
+func FetchComments(taskID uint, req dto.FetchCommentsReq) (dto.FetchCommentsResp, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return dto.FetchCommentsResp{}, err
+	}
+	return fetchComments(config.DB, taskID, req)
+}
+
+// UpdateComment 修改评论内容，只有作者可以修改
+func UpdateComment(ctx context.Context, taskID, id uint, req dto.UpdateCommentReq) (dto.CommentDTO, error) {
+	var comment models.Comment
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if comment, err = findOwnComment(ctx, tx, taskID, id); err != nil {
+			return err
+		}
+		if err = comment.UpdateBody(tx, req.Body); err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.CommentDTO{}, err
+	}
+	return toCommentDTO(comment), nil
+}
+
+// DeleteComment 删除评论及其全部回复，只有作者可以删除
+func DeleteComment(ctx context.Context, taskID, id uint) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		comment, err := findOwnComment(ctx, tx, taskID, id)
+		if err != nil {
+			return err
+		}
+		if _, err = comment.Delete(tx); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+		return nil
+	})
+}
+
+// findOwnComment 查询任务下的评论，并校验当前调用方是否为作者；匿名发表的评论任何人都可以修改
+func findOwnComment(ctx context.Context, tx *gorm.DB, taskID, id uint) (models.Comment, error) {
+	if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+		return models.Comment{}, err
+	}
+
+	var comment models.Comment
+	if err := comment.FetchByID(tx, taskID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Comment{}, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+		}
+		return models.Comment{}, fmt.Errorf("failed to find comment: %w", err)
+	}
+	if comment.Author != "" && comment.Author != models.ChangeContextFrom(ctx).Actor {
+		return models.Comment{}, fmt.Errorf("%w: comment %d", ErrCommentForbidden, id)
+	}
+	return comment, nil
+}
+
+// fetchComments 查询任务的全部评论并组织为回复树，按顶层评论分页
+func fetchComments(db *gorm.DB, taskID uint, req dto.FetchCommentsReq) (dto.FetchCommentsResp, error) {
+	var model models.Comment
+	comments, err := model.FetchByTask(db, taskID)
+	if err != nil {
+		return dto.FetchCommentsResp{}, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	// 评论按 ID 升序排列，回复总是在所回复的评论之后
+	replies := make(map[uint][]models.Comment)
+	var roots []models.Comment
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+		} else {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		}
+	}
+
+	var build func(comment models.Comment) dto.CommentDTO
+	build = func(comment models.Comment) dto.CommentDTO {
+		commentDTO := toCommentDTO(comment)
+		for _, reply := range replies[comment.ID] {
+			commentDTO.Replies = append(commentDTO.Replies, build(reply))
+		}
+		return commentDTO
+	}
+
+	resp := dto.FetchCommentsResp{
+		Comments: []dto.CommentDTO{},
+		Total:    int64(len(roots)),
+		Page:     req.Page,
+		Limit:    req.Limit,
+	}
+	start := (req.Page - 1) * req.Limit
+	for i := start; i < len(roots) && i < start+req.Limit; i++ {
+		resp.Comments = append(resp.Comments, build(roots[i]))
+	}
+	return resp, nil
+}
+
+// toCommentDTO 将评论模型转换为 CommentDTO，不包含回复
+func toCommentDTO(c models.Comment) dto.CommentDTO {
+	return dto.CommentDTO{
+		ID:        c.ID,
+		TaskID:    c.TaskID,
+		ParentID:  c.ParentID,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+		Replies:   []dto.CommentDTO{},
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/models"
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+)
+
+// FieldErrors 字段级校验错误，key 为字段名，value 为错误说明
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	msgs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		msgs = append(msgs, field+": "+e[field])
+	}
+	return "invalid fields: " + strings.Join(msgs, "; ")
+}
+
+func init() {
+	apperrors.RegisterTranslator(translateError)
+}
+
+// translateError 将 models 和 services 中定义的错误转换为带错误码的应用错误
+func translateError(err error) *apperrors.Error {
+	var conflict *models.VersionConflictError
+	var fieldErrs FieldErrors
+
+	switch {
+	case errors.As(err, &conflict):
+		return apperrors.Wrap(apperrors.CodeVersionConflict, err, apperrors.CodeVersionConflict.Title).WithData(toTaskDTO(conflict.Current))
+	case errors.As(err, &fieldErrs):
+		return apperrors.Wrap(apperrors.CodeValidation, err, apperrors.CodeValidation.Title).WithFields(fieldErrs)
+	case errors.Is(err, ErrInvalidPatch), errors.Is(err, ErrInvalidExpand), errors.Is(err, ErrInvalidBulkChanges):
+		return apperrors.Wrap(apperrors.CodeValidation, err, "")
+	case errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrWebhookNotFound), errors.Is(err, ErrWebhookDeliveryNotFound),
+		errors.Is(err, ErrOperationNotFound), errors.Is(err, ErrCommentNotFound), errors.Is(err, ErrAttachmentNotFound),
+		errors.Is(err, ErrChecklistItemNotFound), errors.Is(err, ErrTaskMemberNotFound), errors.Is(err, ErrNotificationNotFound),
+		errors.Is(err, ErrReminderNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.Wrap(apperrors.CodeNotFound, err, "")
+	case errors.Is(err, ErrAttachmentTooLarge):
+		return apperrors.Wrap(apperrors.CodePayloadTooLarge, err, "")
+	case errors.Is(err, ErrCommentForbidden):
+		return apperrors.Wrap(apperrors.CodeForbidden, err, "")
+	case errors.Is(err, ErrUndoExpired):
+		return apperrors.Wrap(apperrors.CodeUndoExpired, err, "")
+	case errors.Is(err, models.ErrTaskAlreadyCompleted), errors.Is(err, models.ErrTaskNotDeleted),
+		errors.Is(err, models.ErrOperationAlreadyUndone), errors.Is(err, models.ErrChecklistIncomplete):
+		return apperrors.Wrap(apperrors.CodeConflict, err, "")
+	case errors.Is(err, models.ErrBatchAborted):
+		return apperrors.Wrap(apperrors.CodeBatchAborted, err, "")
+	case errors.Is(err, ErrIdempotencyKeyMismatch):
+		return apperrors.Wrap(apperrors.CodeIdempotencyKeyReused, err, "")
+	case errors.Is(err, ErrIdempotencyKeyInProgress):
+		return apperrors.Wrap(apperrors.CodeIdempotencyInProgress, err, "")
+	}
+	return nil
+}
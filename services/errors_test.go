@@ -0,0 +1,49 @@
+package services
+
+import (
+	"E-Todo/apperrors"
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"context"
+	"testing"
+)
+
+// TestRestoreActiveTaskConflict 恢复不在回收站中的任务返回冲突，不存在的任务返回 not found
+func TestRestoreActiveTaskConflict(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", Category: "home", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RestoreTask(ctx, task.ID, 0)
+	if got := apperrors.From(err).Code; got != apperrors.CodeConflict {
+		t.Fatalf("restore active task: code = %v, want %v (err %v)", got, apperrors.CodeConflict, err)
+	}
+	err = RestoreTask(ctx, task.ID+100, 0)
+	if got := apperrors.From(err).Code; got != apperrors.CodeNotFound {
+		t.Fatalf("restore missing task: code = %v, want %v (err %v)", got, apperrors.CodeNotFound, err)
+	}
+
+	if err := SoftDelete(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreTask(ctx, task.ID, 0); err != nil {
+		t.Fatalf("restore deleted task: %v", err)
+	}
+}
+
+// TestWithDataCopies 附加数据与字段错误不修改共享的错误值
+func TestWithDataCopies(t *testing.T) {
+	shared := apperrors.New(apperrors.CodeConflict, "")
+	if e := shared.WithData("x"); e == shared || e.Data != "x" {
+		t.Fatalf("WithData returned %+v", e)
+	}
+	if e := shared.WithFields(map[string]string{"title": "required"}); e == shared || e.Fields["title"] != "required" {
+		t.Fatalf("WithFields returned %+v", e)
+	}
+	if shared.Data != nil || shared.Fields != nil {
+		t.Fatalf("shared error mutated: %+v", shared)
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/models"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// taskEventBatchSize 每次从事件日志读取的最大事件数
+const taskEventBatchSize = 500
+
+// taskEventBuffer 每个订阅者的事件缓冲区大小，缓冲区满时断开该订阅者，由客户端通过 Last-Event-ID 续传
+const taskEventBuffer = 256
+
+// taskEventGapWait 事件 ID 空缺的等待时长：先分配 ID 的事务可能晚于后分配的提交，超过该时长仍未读到时视为已回滚
+const taskEventGapWait = time.Minute
+
+// taskEventSubscriber 事件订阅者
+type taskEventSubscriber struct {
+	viewer string
+	ch     chan dto.TaskEvent
+}
+
+// taskEventHub 将事件日志中的新事件推送给当前进程内的订阅者（SSE、WebSocket）
+// 每个实例各自读取事件日志（见 StartTaskEventTail），多实例部署时所有实例的订阅者都能收到全部事件
+type taskEventHub struct {
+	mu          sync.Mutex
+	subscribers map[*taskEventSubscriber]struct{}
+}
+
+var taskEvents = &taskEventHub{subscribers: map[*taskEventSubscriber]struct{}{}}
+
+// taskEventTail 当前实例读取事件日志的位置
+type taskEventTail struct {
+	ready  bool                 // 是否已定位到启动时的最新事件
+	lastID uint64               // 已读取的最大事件 ID
+	gaps   map[uint64]time.Time // 小于 lastID 但尚未读到的事件 ID 及发现时间
+}
+
+// StartTaskEventTail 启动后台任务，按 interval 周期读取事件日志中的新事件并推送给当前进程内的订阅者
+// 从启动时的最新事件开始读取，之前的事件由客户端通过 Last-Event-ID 续传；实时推送不经过发件箱，各实例互不影响
+func StartTaskEventTail(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tail := &taskEventTail{gaps: map[uint64]time.Time{}}
+		for range ticker.C {
+			if err := tail.poll(taskEvents); err != nil {
+				log.Printf("Task event tail: %v", err)
+			}
+		}
+	}()
+}
+
+// poll 将 lastID 之后的事件及之前空缺的事件推送给 hub
+func (t *taskEventTail) poll(hub *taskEventHub) error {
+	var model models.TaskEvent
+	if !t.ready {
+		return t.start()
+	}
+
+	now := time.Now()
+	if len(t.gaps) > 0 {
+		ids := make([]uint64, 0, len(t.gaps))
+		for id, seen := range t.gaps {
+			if now.Sub(seen) > taskEventGapWait {
+				delete(t.gaps, id)
+				continue
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			records, err := model.FetchByIDs(ids)
+			if err != nil {
+				return fmt.Errorf("failed to fetch task events: %w", err)
+			}
+			for _, record := range records {
+				delete(t.gaps, record.ID)
+				t.broadcast(hub, record)
+			}
+		}
+	}
+
+	for {
+		records, err := model.FetchSince(t.lastID, taskEventBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch task events: %w", err)
+		}
+		for _, record := range records {
+			t.advance(record.ID, now)
+			t.broadcast(hub, record)
+		}
+		if len(records) < taskEventBatchSize {
+			return nil
+		}
+	}
+}
+
+// start 定位到启动时的最新事件，并记录其前面最近的、仍可能提交的空缺，之后提交时补推
+func (t *taskEventTail) start() error {
+	var model models.TaskEvent
+	latest, err := model.LatestID()
+	if err != nil {
+		return fmt.Errorf("failed to find the latest task event: %w", err)
+	}
+	if latest > taskEventBatchSize {
+		t.lastID = latest - taskEventBatchSize
+	}
+	records, err := model.FetchSince(t.lastID, taskEventBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch task events: %w", err)
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		if record.ID > latest {
+			break
+		}
+		if gapPending(record, now) {
+			t.advance(record.ID, record.CreatedAt)
+		} else {
+			t.lastID = record.ID
+		}
+	}
+	t.lastID, t.ready = latest, true
+	return nil
+}
+
+// advance 将读取位置移动到 id，其间未读到的 ID 记为空缺
+func (t *taskEventTail) advance(id uint64, seen time.Time) {
+	for gap := t.lastID + 1; gap < id && len(t.gaps) < taskEventBatchSize; gap++ {
+		t.gaps[gap] = seen
+	}
+	t.lastID = id
+}
+
+// resumeID 断线续传的安全位置：不大于该 ID 的事件均已推送或已视为回滚
+func (t *taskEventTail) resumeID() uint64 {
+	resume := t.lastID
+	for gap := range t.gaps {
+		if gap <= resume {
+			resume = gap - 1
+		}
+	}
+	return resume
+}
+
+// broadcast 将事件记录推送给 hub，无法解析的事件记录日志后跳过
+func (t *taskEventTail) broadcast(hub *taskEventHub, record models.TaskEvent) {
+	event, err := toTaskEventDTO(record)
+	if err != nil {
+		log.Printf("Task event tail: %v", err)
+		return
+	}
+	event.Resume = t.resumeID()
+	hub.broadcast(event)
+}
+
+// gapPending 判断 record 之前的 ID 空缺是否仍可能提交，超过 taskEventGapWait 的空缺视为已回滚
+func gapPending(record models.TaskEvent, now time.Time) bool {
+	return now.Sub(record.CreatedAt) <= taskEventGapWait
+}
+
+// SubscribeTaskEvents 订阅 viewer 可见的任务变更事件，返回事件通道和取消订阅函数
+// 订阅者处理过慢时通道会被关闭
+func SubscribeTaskEvents(viewer string) (<-chan dto.TaskEvent, func()) {
+	sub := &taskEventSubscriber{viewer: viewer, ch: make(chan dto.TaskEvent, taskEventBuffer)}
+
+	taskEvents.mu.Lock()
+	taskEvents.subscribers[sub] = struct{}{}
+	taskEvents.mu.Unlock()
+
+	return sub.ch, func() {
+		taskEvents.mu.Lock()
+		defer taskEvents.mu.Unlock()
+		if _, ok := taskEvents.subscribers[sub]; ok {
+			delete(taskEvents.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// broadcast 将事件推送给所有可见该任务的订阅者
+func (h *taskEventHub) broadcast(event dto.TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !TaskVisibleTo(sub.viewer, event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// TaskVisibleTo 判断事件对调用方是否可见：没有负责人和关注者的任务所有人可见，
+// 否则仅负责人、关注者和任务的创建者可见；可见范围在事件发生时确定，硬删除的任务取删除前的成员
+func TaskVisibleTo(viewer string, event dto.TaskEvent) bool {
+	return audienceIncludes(event.Audience, viewer)
+}
+
+// audienceIncludes 判断 viewer 是否在可见范围内，可见范围为空表示所有人可见
+func audienceIncludes(audience []string, viewer string) bool {
+	if len(audience) == 0 {
+		return true
+	}
+	for _, user := range audience {
+		if user == viewer {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayTaskEvents 按顺序将 viewer 可见的、在 afterID 之后发生的事件交给 fn，用于断线续传
+// 与实时推送相同，续传位置（事件的 Resume）停在第一个仍可能提交的 ID 空缺之前，空缺之后的事件照常推送，
+// 从该位置再次续传时会重复收到这些事件；返回续传位置，fn 返回错误时停止
+func ReplayTaskEvents(viewer string, afterID uint64, fn func(event dto.TaskEvent) error) (uint64, error) {
+	var model models.TaskEvent
+	resume, pending := afterID, false
+	now := time.Now()
+	for {
+		records, err := model.FetchSince(afterID, taskEventBatchSize)
+		if err != nil {
+			return resume, fmt.Errorf("failed to fetch task events: %w", err)
+		}
+
+		for _, record := range records {
+			if record.ID > afterID+1 && gapPending(record, now) {
+				pending = true
+			}
+			afterID = record.ID
+			if !pending {
+				resume = record.ID
+			}
+
+			event, err := toTaskEventDTO(record)
+			if err != nil {
+				return resume, err
+			}
+			event.Resume = resume
+			if TaskVisibleTo(viewer, event) {
+				if err = fn(event); err != nil {
+					return resume, err
+				}
+			}
+		}
+
+		if len(records) < taskEventBatchSize {
+			return resume, nil
+		}
+	}
+}
+
+// toTaskEventDTO 将事件记录转换为 DTO
+func toTaskEventDTO(record models.TaskEvent) (dto.TaskEvent, error) {
+	task, err := record.Task()
+	if err != nil {
+		return dto.TaskEvent{}, fmt.Errorf("failed to decode task event %d: %w", record.ID, err)
+	}
+	audience, err := record.Viewers()
+	if err != nil {
+		return dto.TaskEvent{}, fmt.Errorf("failed to decode task event %d: %w", record.ID, err)
+	}
+	return dto.TaskEvent{
+		ID:        record.ID,
+		Type:      record.Type,
+		Task:      toTaskDTO(task),
+		CreatedAt: record.CreatedAt.Format(time.RFC3339),
+		Audience:  audience,
+	}, nil
+}
+
+// StartTaskEventCleanup 启动后台任务，按 interval 周期删除超过保留天数的事件，retentionDays <= 0 时不启动
+func StartTaskEventCleanup(retentionDays int, interval time.Duration) {
+	if retentionDays <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var model models.TaskEvent
+			if _, err := model.PurgeBefore(time.Now().AddDate(0, 0, -retentionDays)); err != nil {
+				log.Printf("Task event cleanup: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// replayTypes 续传 viewer 可见的全部事件，返回事件类型
+func replayTypes(t *testing.T, viewer string) []string {
+	var types []string
+	_, err := ReplayTaskEvents(viewer, 0, func(event dto.TaskEvent) error {
+		types = append(types, event.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types
+}
+
+// TestTaskEventVisibility 没有成员的任务所有人可见，有成员后仅成员和创建者可见，硬删除事件取删除前的成员
+func TestTaskEventVisibility(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AddTaskMember(ctx, task.ID, models.TaskMemberAssignee, dto.AddTaskMemberReq{User: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = CompleteTask(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = DeleteTask(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	all := []string{models.TaskEventCreated, models.TaskEventCompleted, models.TaskEventDeleted}
+	for viewer, want := range map[string][]string{
+		"alice": all,
+		"bob":   all,
+		"carol": {models.TaskEventCreated},
+		"":      {models.TaskEventCreated},
+	} {
+		if got := replayTypes(t, viewer); !reflect.DeepEqual(got, want) {
+			t.Errorf("viewer %q replayed %v, want %v", viewer, got, want)
+		}
+	}
+}
+
+// TestTaskEventVisibilityMentions 描述中提及的用户成为关注者，创建事件仅对其和创建者可见
+func TestTaskEventVisibilityMentions(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+
+	if _, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", Description: "ping @dave", DueDate: "2030-01-01T00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, unsubscribe := SubscribeTaskEvents("dave")
+	defer unsubscribe()
+	var model models.TaskEvent
+	records, err := model.FetchSince(0, taskEventBatchSize)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("got %d events: %v", len(records), err)
+	}
+	event, err := toTaskEventDTO(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dave", "alice"}; !reflect.DeepEqual(event.Audience, want) {
+		t.Fatalf("audience %v, want %v", event.Audience, want)
+	}
+
+	// 实时推送与续传使用相同的规则
+	taskEvents.broadcast(event)
+	select {
+	case got := <-events:
+		if got.ID != event.ID {
+			t.Errorf("got event %d, want %d", got.ID, event.ID)
+		}
+	default:
+		t.Error("visible event was not broadcast")
+	}
+	if got := replayTypes(t, "carol"); len(got) != 0 {
+		t.Errorf("carol replayed %v, want none", got)
+	}
+}
+
+// TestTaskEventTail 每个实例从启动时的最新事件开始读取，晚提交的空缺事件在之后补推
+func TestTaskEventTail(t *testing.T) {
+	db := testdb.Open(t)
+	record := func(id uint64) {
+		event := models.TaskEvent{ID: id, Type: models.TaskEventCreated, TaskID: 1, Payload: `{"ID":1}`}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(1)
+
+	events, unsubscribe := SubscribeTaskEvents("")
+	defer unsubscribe()
+	received := func() []uint64 {
+		var ids []uint64
+		for {
+			select {
+			case event := <-events:
+				ids = append(ids, event.ID)
+			default:
+				return ids
+			}
+		}
+	}
+
+	tail := &taskEventTail{gaps: map[uint64]time.Time{}}
+	poll := func() {
+		if err := tail.poll(taskEvents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	poll()
+	if got := received(); len(got) != 0 {
+		t.Fatalf("events before the tail started were pushed: %v", got)
+	}
+
+	// 事件 2 的事务晚于事件 3 提交，续传位置在事件 2 推送前不越过它
+	record(3)
+	poll()
+	if got := <-events; got.ID != 3 || got.Resume != 1 {
+		t.Fatalf("pushed event %d with resume %d, want 3 with resume 1", got.ID, got.Resume)
+	}
+	record(2)
+	poll()
+	poll()
+	if got := <-events; got.ID != 2 || got.Resume != 3 {
+		t.Fatalf("pushed event %d with resume %d, want 2 with resume 3", got.ID, got.Resume)
+	}
+	if got := received(); len(got) != 0 {
+		t.Fatalf("pushed %v twice", got)
+	}
+
+	// 超过等待时长的空缺视为已回滚
+	record(6)
+	poll()
+	for id := range tail.gaps {
+		tail.gaps[id] = time.Now().Add(-2 * taskEventGapWait)
+	}
+	record(4)
+	poll()
+	if got, want := received(), []uint64{6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pushed %v, want %v", got, want)
+	}
+	if len(tail.gaps) != 0 {
+		t.Errorf("expired gaps kept: %v", tail.gaps)
+	}
+}
+
+// TestTaskEventTailStartsWithRecentGaps 启动前已存在的、仍可能提交的空缺在提交后补推
+func TestTaskEventTailStartsWithRecentGaps(t *testing.T) {
+	db := testdb.Open(t)
+	record := func(id uint64) {
+		event := models.TaskEvent{ID: id, Type: models.TaskEventCreated, TaskID: 1, Payload: `{"ID":1}`}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(1)
+	record(3)
+
+	events, unsubscribe := SubscribeTaskEvents("")
+	defer unsubscribe()
+	tail := &taskEventTail{gaps: map[uint64]time.Time{}}
+	for i := 0; i < 2; i++ {
+		if err := tail.poll(taskEvents); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			record(2)
+		}
+	}
+	select {
+	case got := <-events:
+		if got.ID != 2 || got.Resume != 3 {
+			t.Errorf("pushed event %d with resume %d, want 2 with resume 3", got.ID, got.Resume)
+		}
+	default:
+		t.Error("the late event before the tail started was not pushed")
+	}
+}
+
+// TestReplayTaskEventsStopsResumeAtGap 补发时续传位置停在仍可能提交的空缺之前，从该位置续传时补发晚提交的事件
+func TestReplayTaskEventsStopsResumeAtGap(t *testing.T) {
+	db := testdb.Open(t)
+	record := func(id uint64, createdAt time.Time) {
+		event := models.TaskEvent{ID: id, Type: models.TaskEventCreated, TaskID: 1, Payload: `{"ID":1}`, CreatedAt: createdAt}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	replay := func(afterID uint64) ([]uint64, []uint64, uint64) {
+		var ids, resumes []uint64
+		resume, err := ReplayTaskEvents("", afterID, func(event dto.TaskEvent) error {
+			ids = append(ids, event.ID)
+			resumes = append(resumes, event.Resume)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids, resumes, resume
+	}
+
+	now := time.Now()
+	record(1, now)
+	record(2, now)
+	record(4, now)
+	ids, resumes, resume := replay(0)
+	if !reflect.DeepEqual(ids, []uint64{1, 2, 4}) || !reflect.DeepEqual(resumes, []uint64{1, 2, 2}) || resume != 2 {
+		t.Fatalf("replayed %v with resumes %v and position %d", ids, resumes, resume)
+	}
+
+	// 事件 3 晚提交，从续传位置补发时不会丢失
+	record(3, now)
+	if ids, _, resume = replay(resume); !reflect.DeepEqual(ids, []uint64{3, 4}) || resume != 4 {
+		t.Fatalf("replayed %v with position %d, want [3 4] and 4", ids, resume)
+	}
+
+	// 超过等待时长的空缺视为已回滚
+	record(6, now.Add(-2*taskEventGapWait))
+	if ids, _, resume = replay(4); !reflect.DeepEqual(ids, []uint64{6}) || resume != 6 {
+		t.Fatalf("replayed %v with position %d, want [6] and 6", ids, resume)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"E-Todo/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TaskExpander 加载任务的关联数据，供 GET /tasks/:id?expand= 使用
+type TaskExpander func(task models.Task) (interface{}, error)
+
+// taskExpanders 已注册的关联数据，key 为 expand 参数中的名称
+var taskExpanders = map[string]TaskExpander{}
+
+// RegisterTaskExpander 注册一种可展开的关联数据，通常在 init 中调用
+func RegisterTaskExpander(name string, expander TaskExpander) {
+	if _, ok := taskExpanders[name]; ok {
+		panic(fmt.Sprintf("task expander %q already registered", name))
+	}
+	taskExpanders[name] = expander
+}
+
+// TaskExpansions 返回所有可用的 expand 名称
+func TaskExpansions() []string {
+	names := make([]string, 0, len(taskExpanders))
+	for name := range taskExpanders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandTask 按名称加载任务的关联数据
+func expandTask(task models.Task, names []string) (map[string]interface{}, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	expanded := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		expander, ok := taskExpanders[name]
+		if !ok {
+			available := "none"
+			if len(taskExpanders) > 0 {
+				available = strings.Join(TaskExpansions(), ", ")
+			}
+			return nil, fmt.Errorf("%w: unknown expansion %q (available: %s)", ErrInvalidExpand, name, available)
+		}
+
+		data, err := expander(task)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", name, err)
+		}
+		expanded[name] = data
+	}
+	return expanded, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// systemActor 后台任务发起变更时记录的调用方
+const systemActor = "system"
+
+// historyExpandLimit 通过 ?expand=history 返回的最大变更记录数
+const historyExpandLimit = 100
+
+func init() {
+	RegisterTaskExpander("history", func(task models.Task) (interface{}, error) {
+		resp, err := fetchTaskHistory(task.ID, dto.TaskHistoryReq{Page: 1, Limit: historyExpandLimit})
+		if err != nil {
+			return nil, err
+		}
+		return resp.History, nil
+	})
+}
+
+// WithCaller 返回携带调用方标识和请求 ID 的 context，经由该 context 发起的任务变更会记录到变更历史中
+func WithCaller(ctx context.Context, actor, requestID string) context.Context {
+	return models.WithChangeContext(ctx, models.ChangeContext{Actor: actor, RequestID: requestID})
+}
+
+// systemContext 后台任务使用的 context
+func systemContext() context.Context {
+	return WithCaller(context.Background(), systemActor, "")
+}
+
+// GetTaskHistory 按时间顺序分页获取任务的变更历史
+// 任务被硬删除后仍可查询其变更历史，没有任何历史的任务视为不存在
+func GetTaskHistory(id uint, req dto.TaskHistoryReq) (dto.TaskHistoryResp, error) {
+	resp, err := fetchTaskHistory(id, req)
+	if err != nil {
+		return dto.TaskHistoryResp{}, err
+	}
+	if resp.Total > 0 {
+		return resp, nil
+	}
+
+	// 历史记录功能上线前创建的任务没有历史
+	var task models.Task
+	task.ID = id
+	if err = task.FindTaskByID(config.DB); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.TaskHistoryResp{}, fmt.Errorf("%w: %d", ErrTaskNotFound, id)
+		}
+		return dto.TaskHistoryResp{}, fmt.Errorf("failed to find task: %w", err)
+	}
+	return resp, nil
+}
+
+// fetchTaskHistory 查询任务的变更历史
+func fetchTaskHistory(id uint, req dto.TaskHistoryReq) (dto.TaskHistoryResp, error) {
+	var model models.TaskHistory
+	history, total, err := model.FetchByTask(id, req.Page, req.Limit)
+	if err != nil {
+		return dto.TaskHistoryResp{}, fmt.Errorf("failed to fetch task history: %w", err)
+	}
+
+	resp := dto.TaskHistoryResp{
+		History: make([]dto.TaskHistoryDTO, 0, len(history)),
+		Total:   total,
+		Page:    req.Page,
+		Limit:   req.Limit,
+	}
+	for _, h := range history {
+		resp.History = append(resp.History, dto.TaskHistoryDTO{
+			ID:          h.ID,
+			TaskID:      h.TaskID,
+			OperationID: h.OperationID,
+			Action:      h.Action,
+			Field:       h.Field,
+			OldValue:    h.OldValue,
+			NewValue:    h.NewValue,
+			Actor:       h.Actor,
+			RequestID:   h.RequestID,
+			CreatedAt:   h.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"E-Todo/models"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyMismatch 幂等键已被用于不同的请求
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+	// ErrIdempotencyKeyInProgress 使用同一幂等键的请求仍在处理中
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// BeginIdempotentRequest 登记调用方的一次携带幂等键的请求
+// 若已有相同请求的已完成记录，返回该记录用于重放；否则登记新记录并返回 nil
+func BeginIdempotentRequest(caller, key, requestHash string, ttl time.Duration) (*models.IdempotencyKey, error) {
+	now := time.Now()
+
+	var record models.IdempotencyKey
+	err := record.FindByKey(caller, key)
+	switch {
+	case err == nil:
+		if record.Expired(now) {
+			// 过期记录视为不存在
+			if err = record.Delete(); err != nil {
+				return nil, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+			}
+			break
+		}
+		if record.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if !record.Completed {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return &record, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to query idempotency key: %w", err)
+	}
+
+	record = models.IdempotencyKey{
+		Caller:      caller,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(ttl),
+	}
+	created, err := record.CreateIfAbsent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	if !created {
+		// 并发请求抢先登记了同一个键
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return nil, nil
+}
+
+// CompleteIdempotentRequest 保存请求的响应，后续重试将重放该响应
+func CompleteIdempotentRequest(caller, key string, statusCode int, contentType string, body []byte) error {
+	var record models.IdempotencyKey
+	if err := record.FindByKey(caller, key); err != nil {
+		return fmt.Errorf("failed to query idempotency key: %w", err)
+	}
+	if err := record.Complete(statusCode, contentType, body); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// AbandonIdempotentRequest 删除未完成的幂等键记录，允许客户端使用同一个键重试
+func AbandonIdempotentRequest(caller, key string) error {
+	var record models.IdempotencyKey
+	if err := record.FindByKey(caller, key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to query idempotency key: %w", err)
+	}
+	if err := record.Delete(); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// StartIdempotencyKeyCleanup 启动后台任务，按 interval 周期删除过期的幂等键记录
+func StartIdempotencyKeyCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var record models.IdempotencyKey
+			if _, err := record.PurgeExpired(time.Now()); err != nil {
+				log.Printf("Idempotency key cleanup: %v", err)
+			}
+		}
+	}()
+}
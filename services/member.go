@@ -0,0 +1,121 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTaskMemberNotFound 用户不是任务的负责人或关注者
+var ErrTaskMemberNotFound = errors.New("task member not found")
+
+// meUser 表示当前调用方的用户标识
+const meUser = "me"
+
+// 任务成员的角色
+const (
+	RoleAssignee = models.TaskMemberAssignee
+	RoleWatcher  = models.TaskMemberWatcher
+)
+
+func init() {
+	RegisterTaskExpander("members", func(task models.Task) (interface{}, error) {
+		return fetchTaskMembers(config.DB, task.ID)
+	})
+}
+
+// FetchTaskMembers 获取任务的负责人和关注者
+func FetchTaskMembers(taskID uint) (dto.TaskMembersDTO, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return dto.TaskMembersDTO{}, err
+	}
+	return fetchTaskMembers(config.DB, taskID)
+}
+
+// AddTaskMember 将用户添加为任务的负责人或关注者，用户为空或 me 时为当前调用方；已是成员时不做修改
+func AddTaskMember(ctx context.Context, taskID uint, role string, req dto.AddTaskMemberReq) (dto.TaskMembersDTO, error) {
+	if req.User == "" {
+		req.User = meUser
+	}
+	user, err := resolveMe(ctx, "user", req.User)
+	if err != nil {
+		return dto.TaskMembersDTO{}, err
+	}
+
+	var members dto.TaskMembersDTO
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		member := models.TaskMember{TaskID: taskID, User: user, Role: role}
+		if _, err := member.Add(tx); err != nil {
+			return fmt.Errorf("failed to add task %s: %w", role, err)
+		}
+		members, err = fetchTaskMembers(tx, taskID)
+		return err
+	})
+	if err != nil {
+		return dto.TaskMembersDTO{}, err
+	}
+	return members, nil
+}
+
+// RemoveTaskMember 移除任务的负责人或关注者，用户为 me 时为当前调用方
+func RemoveTaskMember(ctx context.Context, taskID uint, role, user string) error {
+	user, err := resolveMe(ctx, "user", user)
+	if err != nil {
+		return err
+	}
+
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		member := models.TaskMember{TaskID: taskID, User: user, Role: role}
+		removed, err := member.Remove(tx)
+		if err != nil {
+			return fmt.Errorf("failed to remove task %s: %w", role, err)
+		}
+		if !removed {
+			return fmt.Errorf("%w: %s is not a %s of task %d", ErrTaskMemberNotFound, user, role, taskID)
+		}
+		return nil
+	})
+}
+
+// resolveMe 将 me 替换为当前调用方的标识，未提供 X-User 时返回字段校验错误
+func resolveMe(ctx context.Context, field, user string) (string, error) {
+	if user != meUser {
+		return user, nil
+	}
+	actor := models.ChangeContextFrom(ctx).Actor
+	if actor == "" || actor == systemActor {
+		return "", FieldErrors{field: "me requires the X-User header"}
+	}
+	return actor, nil
+}
+
+// fetchTaskMembers 查询任务的负责人和关注者，按加入顺序排列
+func fetchTaskMembers(db *gorm.DB, taskID uint) (dto.TaskMembersDTO, error) {
+	var model models.TaskMember
+	members, err := model.FetchByTask(db, taskID)
+	if err != nil {
+		return dto.TaskMembersDTO{}, fmt.Errorf("failed to fetch task members: %w", err)
+	}
+
+	resp := dto.TaskMembersDTO{Assignees: []string{}, Watchers: []string{}}
+	for _, member := range members {
+		switch member.Role {
+		case models.TaskMemberAssignee:
+			resp.Assignees = append(resp.Assignees, member.User)
+		case models.TaskMemberWatcher:
+			resp.Watchers = append(resp.Watchers, member.User)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ErrNotificationNotFound 通知不存在或不属于当前调用方
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// FetchNotifications 按时间倒序分页获取用户的通知
+func FetchNotifications(recipient string, req dto.FetchNotificationsReq) (dto.FetchNotificationsResp, error) {
+	var model models.Notification
+	notifications, total, err := model.FetchByRecipient(config.DB, recipient, req.Unread, req.Page, req.Limit)
+	if err != nil {
+		return dto.FetchNotificationsResp{}, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	unread, err := model.CountUnread(config.DB, recipient)
+	if err != nil {
+		return dto.FetchNotificationsResp{}, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	resp := dto.FetchNotificationsResp{
+		Notifications: make([]dto.NotificationDTO, 0, len(notifications)),
+		Total:         total,
+		Unread:        unread,
+		Page:          req.Page,
+		Limit:         req.Limit,
+	}
+	for _, notification := range notifications {
+		resp.Notifications = append(resp.Notifications, toNotificationDTO(notification))
+	}
+	return resp, nil
+}
+
+// MarkNotificationRead 将用户的一条通知标为已读
+func MarkNotificationRead(recipient string, id uint64) (dto.NotificationDTO, error) {
+	var notification models.Notification
+	if err := notification.MarkRead(config.DB, recipient, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.NotificationDTO{}, fmt.Errorf("%w: %d", ErrNotificationNotFound, id)
+		}
+		return dto.NotificationDTO{}, fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	return toNotificationDTO(notification), nil
+}
+
+// MarkAllNotificationsRead 将用户的全部未读通知标为已读
+func MarkAllNotificationsRead(recipient string) (dto.MarkAllNotificationsReadResp, error) {
+	var model models.Notification
+	marked, err := model.MarkAllRead(config.DB, recipient)
+	if err != nil {
+		return dto.MarkAllNotificationsReadResp{}, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return dto.MarkAllNotificationsReadResp{Marked: marked}, nil
+}
+
+// toNotificationDTO 将通知模型转换为 NotificationDTO
+func toNotificationDTO(n models.Notification) dto.NotificationDTO {
+	return dto.NotificationDTO{
+		ID:        n.ID,
+		Type:      n.Type,
+		TaskID:    n.TaskID,
+		CommentID: n.CommentID,
+		Actor:     n.Actor,
+		Excerpt:   n.Excerpt,
+		Read:      n.ReadAt != nil,
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+var (
+	// ErrOperationNotFound 操作不存在或已被清理
+	ErrOperationNotFound = errors.New("operation not found")
+	// ErrUndoExpired 操作已超过可撤销的时长
+	ErrUndoExpired = errors.New("undo window expired")
+)
+
+// FetchOperations 按时间倒序分页获取任务操作
+func FetchOperations(req dto.FetchOperationsReq) (dto.FetchOperationsResp, error) {
+	var model models.TaskOperation
+	operations, total, err := model.FetchAll(req.Actor, req.RequestID, req.Page, req.Limit)
+	if err != nil {
+		return dto.FetchOperationsResp{}, fmt.Errorf("failed to fetch operations: %w", err)
+	}
+
+	resp := dto.FetchOperationsResp{
+		Operations: make([]dto.OperationDTO, 0, len(operations)),
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+	}
+	for _, operation := range operations {
+		resp.Operations = append(resp.Operations, toOperationDTO(operation))
+	}
+	return resp, nil
+}
+
+// GetOperation 获取单个任务操作
+func GetOperation(id uint64) (dto.OperationDTO, error) {
+	operation, err := findOperation(id)
+	if err != nil {
+		return dto.OperationDTO{}, err
+	}
+	return toOperationDTO(operation), nil
+}
+
+// UndoOperation 撤销操作，将涉及的任务恢复为操作前的状态
+// 操作之后又被修改或删除的任务无法撤销，在结果中返回其当前状态；atomic 为 true 时此时整体回滚
+func UndoOperation(ctx context.Context, id uint64, req dto.UndoOperationReq) (dto.UndoOperationResp, error) {
+	operation, err := findOperation(id)
+	if err != nil {
+		return dto.UndoOperationResp{}, err
+	}
+	if operation.UndoneAt == nil && time.Since(operation.CreatedAt) > config.UndoWindow() {
+		return dto.UndoOperationResp{}, fmt.Errorf("%w: operation %d", ErrUndoExpired, id)
+	}
+
+	undo, results, err := operation.Undo(config.DB.WithContext(ctx), req.Atomic)
+	resp := toUndoOperationResp(results)
+	if err != nil {
+		return resp, fmt.Errorf("failed to undo operation: %w", err)
+	}
+	if undo.ID != 0 {
+		if err = undo.FetchByID(undo.ID); err != nil {
+			return resp, fmt.Errorf("failed to reload operation: %w", err)
+		}
+		undoDTO := toOperationDTO(undo)
+		resp.Operation = &undoDTO
+	}
+	return resp, nil
+}
+
+// StartTaskOperationCleanup 启动后台任务，按 interval 周期删除超过可撤销时长的操作及其快照
+func StartTaskOperationCleanup(window, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var model models.TaskOperation
+			if _, err := model.PurgeBefore(time.Now().Add(-window)); err != nil {
+				log.Printf("Task operation cleanup: %v", err)
+			}
+		}
+	}()
+}
+
+// findOperation 根据 ID 查询操作
+func findOperation(id uint64) (models.TaskOperation, error) {
+	var operation models.TaskOperation
+	if err := operation.FetchByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.TaskOperation{}, fmt.Errorf("%w: %d", ErrOperationNotFound, id)
+		}
+		return models.TaskOperation{}, fmt.Errorf("failed to find operation: %w", err)
+	}
+	return operation, nil
+}
+
+// toOperationDTO 将操作模型转换为 OperationDTO
+func toOperationDTO(o models.TaskOperation) dto.OperationDTO {
+	expiresAt := o.CreatedAt.Add(config.UndoWindow())
+	operationDTO := dto.OperationDTO{
+		ID:        o.ID,
+		Action:    o.Action,
+		TaskIDs:   o.TaskIDs(),
+		Actor:     o.Actor,
+		RequestID: o.RequestID,
+		Undoable:  o.UndoneAt == nil && time.Now().Before(expiresAt),
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		CreatedAt: o.CreatedAt.Format(time.RFC3339),
+	}
+	if o.UndoOf != nil {
+		operationDTO.UndoOf = *o.UndoOf
+	}
+	if o.UndoneAt != nil {
+		operationDTO.UndoneAt = o.UndoneAt.Format(time.RFC3339)
+	}
+	return operationDTO
+}
+
+// toUndoOperationResp 将撤销结果转换为 UndoOperationResp
+func toUndoOperationResp(results []models.UndoItemResult) dto.UndoOperationResp {
+	resp := dto.UndoOperationResp{Results: make([]dto.UndoItemResult, 0, len(results))}
+	for _, r := range results {
+		item := dto.UndoItemResult{TaskID: r.TaskID, Result: r.Result}
+		if r.Task != nil {
+			taskDTO := toTaskDTO(*r.Task)
+			item.Task = &taskDTO
+		}
+		if r.Result == models.BatchResultOK {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	return resp
+}
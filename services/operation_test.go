@@ -0,0 +1,109 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// latestOperation 查询最近的一次操作
+func latestOperation(t *testing.T) dto.OperationDTO {
+	resp, err := FetchOperations(dto.FetchOperationsReq{Page: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Operations) == 0 {
+		t.Fatal("no operations recorded")
+	}
+	return resp.Operations[0]
+}
+
+// TestBulkUpdateRecordsOneOperation 按条件批量更新记录为一次操作，撤销时恢复全部任务
+func TestBulkUpdateRecordsOneOperation(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	var ids []uint
+	for _, title := range []string{"a", "b", "c"} {
+		task, err := CreateTask(ctx, dto.CreateTaskReq{Title: title, Category: "home", DueDate: "2030-01-01T00:00Z"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	work := "work"
+	resp, err := BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{Category: "home"}, Changes: dto.BulkUpdateChanges{Category: &work}})
+	if err != nil || resp.Affected != 3 {
+		t.Fatalf("bulk update: %+v %v", resp, err)
+	}
+	operation := latestOperation(t)
+	if operation.Action != models.TaskEventUpdated || !reflect.DeepEqual(operation.TaskIDs, ids) {
+		t.Fatalf("unexpected operation %+v", operation)
+	}
+
+	undo, err := UndoOperation(ctx, operation.ID, dto.UndoOperationReq{Atomic: true})
+	if err != nil || undo.Succeeded != 3 {
+		t.Fatalf("undo: %+v %v", undo, err)
+	}
+	for _, id := range ids {
+		task, err := GetTask(id, nil)
+		if err != nil || task.Category != "home" {
+			t.Errorf("task %d after undo: %+v %v", id, task, err)
+		}
+	}
+
+	// 试运行不记录操作
+	if _, err = BulkUpdateTasks(ctx, dto.BulkUpdateTasksReq{Filter: dto.BulkUpdateFilter{Category: "home"}, Changes: dto.BulkUpdateChanges{Category: &work}, DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if latest := latestOperation(t); latest.Action != models.TaskActionUndo {
+		t.Errorf("dry run recorded operation %+v", latest)
+	}
+}
+
+// TestExecuteBatchRecordsOneOperation 混合批量操作记录为一次操作，同一任务的多次变更合并，撤销时一并撤销
+func TestExecuteBatchRecordsOneOperation(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	existing, err := CreateTask(ctx, dto.CreateTaskReq{Title: "existing", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := func(v interface{}) json.RawMessage {
+		b, _ := json.Marshal(v)
+		return b
+	}
+	resp, err := ExecuteBatch(ctx, dto.BatchOperationsReq{Operations: []dto.BatchOperation{
+		{Op: models.BatchOpCreate, Ref: "new", Data: data(dto.CreateTaskReq{Title: "new", DueDate: "2030-01-01T00:00Z"})},
+		{Op: models.BatchOpMoveCategory, Ref: "new", Category: "work"},
+		{Op: models.BatchOpComplete, ID: existing.ID},
+		{Op: models.BatchOpCreate, Ref: "tmp", Data: data(dto.CreateTaskReq{Title: "tmp", DueDate: "2030-01-01T00:00Z"})},
+		{Op: models.BatchOpDelete, Ref: "tmp"},
+	}})
+	if err != nil || !resp.Committed {
+		t.Fatalf("batch: %+v %v", resp, err)
+	}
+	created := resp.Results[0].ID
+
+	// 创建后又删除的任务不记录
+	operation := latestOperation(t)
+	if operation.Action != models.TaskActionBatch || !reflect.DeepEqual(operation.TaskIDs, []uint{created, existing.ID}) {
+		t.Fatalf("unexpected operation %+v", operation)
+	}
+
+	undo, err := UndoOperation(ctx, operation.ID, dto.UndoOperationReq{Atomic: true})
+	if err != nil || undo.Succeeded != 2 {
+		t.Fatalf("undo: %+v %v", undo, err)
+	}
+	if _, err = GetTask(created, nil); err == nil {
+		t.Error("task created in the batch should be moved to the trash")
+	}
+	if task, err := GetTask(existing.ID, nil); err != nil || task.Status != models.TaskStatusPending {
+		t.Errorf("existing task after undo: %+v %v", task, err)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	outboxBatchSize  = 500             // 每次发布的最大事件数
+	outboxClaimLease = time.Minute     // 发布占用时长，实例崩溃时超时后由其他实例重新发布
+	outboxRetryBase  = time.Second     // 首次重试的等待时间，之后每次翻倍
+	outboxRetryMax   = 5 * time.Minute // 重试等待时间的上限
+	outboxErrorSize  = 1000            // 保存的错误信息最大长度
+)
+
+// EventSink 任务变更事件的接收方，如 Webhook、日志；实时推送由各实例读取事件日志完成，不经过发件箱
+// 事件至少发布一次：任一接收方失败时该事件会重新发布给所有接收方，实现需能处理重复的事件
+type EventSink func(event dto.TaskEvent) error
+
+// eventSinks 已注册的事件接收方，key 为名称
+var eventSinks = map[string]EventSink{}
+
+// RegisterEventSink 注册事件接收方，需在 StartOutboxDispatcher 之前调用，通常在 init 中调用
+func RegisterEventSink(name string, sink EventSink) {
+	if _, ok := eventSinks[name]; ok {
+		panic(fmt.Sprintf("event sink %q already registered", name))
+	}
+	eventSinks[name] = sink
+}
+
+// LogEventSink 将事件写入日志的接收方
+func LogEventSink(event dto.TaskEvent) error {
+	log.Printf("Task event %d: %s task %d", event.ID, event.Type, event.Task.ID)
+	return nil
+}
+
+// StartOutboxDispatcher 启动后台任务，按 interval 周期将事件日志中待发布的事件按 ID 顺序发布给所有接收方
+// 事件与任务变更在同一事务中写入，进程在提交后、发布前崩溃时，重启后会继续发布，事件不会丢失
+// 多个实例可同时运行，每个事件由占用它的实例发布
+func StartOutboxDispatcher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := dispatchOutbox(); err != nil {
+				log.Printf("Outbox dispatcher: %v", err)
+			}
+		}
+	}()
+}
+
+// dispatchOutbox 发布一批待发布的事件
+func dispatchOutbox() error {
+	var model models.TaskEvent
+	records, err := model.FetchUnpublished(time.Now(), outboxBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch unpublished task events: %w", err)
+	}
+
+	for i := range records {
+		record := records[i]
+		// 占用期限从占用时开始计算，前面的事件发布较慢时不会缩短
+		claimedAt := time.Now()
+		claimed, err := record.Claim(claimedAt, claimedAt.Add(outboxClaimLease))
+		if err != nil {
+			return fmt.Errorf("failed to claim task event %d: %w", record.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if err = publishTaskEvent(record); err != nil {
+			msg := err.Error()
+			if len(msg) > outboxErrorSize {
+				msg = msg[:outboxErrorSize]
+			}
+			retryAt := time.Now().Add(outboxBackoff(record.Attempts + 1))
+			if markErr := record.MarkFailed(msg, retryAt); markErr != nil {
+				return fmt.Errorf("failed to record outbox failure for task event %d: %w", record.ID, markErr)
+			}
+			log.Printf("Outbox dispatcher: task event %d: %v", record.ID, err)
+			continue
+		}
+
+		if err = record.MarkPublished(time.Now()); err != nil {
+			return fmt.Errorf("failed to mark task event %d as published: %w", record.ID, err)
+		}
+	}
+	return nil
+}
+
+// publishTaskEvent 将事件发布给所有接收方，返回各接收方的错误
+func publishTaskEvent(record models.TaskEvent) error {
+	event, err := toTaskEventDTO(record)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(eventSinks))
+	for name := range eventSinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		if err = eventSinks[name](event); err != nil {
+			failed = append(failed, name+": "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to publish to %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// outboxBackoff 第 attempt 次发布失败后的重试等待时间
+func outboxBackoff(attempt int) time.Duration {
+	backoff := outboxRetryBase
+	for i := 1; i < attempt && backoff < outboxRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxRetryMax {
+		backoff = outboxRetryMax
+	}
+	return backoff
+}
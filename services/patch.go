@@ -0,0 +1,315 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPatch 补丁文档格式不合法
+var ErrInvalidPatch = errors.New("invalid patch document")
+
+// 可通过 PATCH 修改的字段
+var patchableFields = map[string]bool{
+	"title":       true,
+	"description": true,
+	"category":    true,
+	"color":       true,
+	"due_date":    true,
+	"status":      true,
+}
+
+// 只读字段
+var readOnlyFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// MergePatchTask 使用 JSON Merge Patch (RFC 7396) 部分更新任务，值为 null 表示清空字段
+func MergePatchTask(ctx context.Context, id uint, version uint, patch []byte) (dto.TaskDTO, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return dto.TaskDTO{}, fmt.Errorf("%w: merge patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	return patchTask(ctx, id, version, func(doc map[string]interface{}) error {
+		fieldErrs := FieldErrors{}
+		for field, raw := range fields {
+			if err := checkPatchField(field); err != "" {
+				fieldErrs[field] = err
+				continue
+			}
+
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err != nil {
+				fieldErrs[field] = "invalid JSON value"
+				continue
+			}
+			if value == nil {
+				delete(doc, field)
+			} else {
+				doc[field] = value
+			}
+		}
+		if len(fieldErrs) > 0 {
+			return fieldErrs
+		}
+		return nil
+	})
+}
+
+// JSONPatchTask 使用 JSON Patch (RFC 6902) 部分更新任务，仅支持顶层字段
+func JSONPatchTask(ctx context.Context, id uint, version uint, patch []byte) (dto.TaskDTO, error) {
+	var ops []dto.JSONPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return dto.TaskDTO{}, fmt.Errorf("%w: JSON patch must be an array of operations", ErrInvalidPatch)
+	}
+
+	return patchTask(ctx, id, version, func(doc map[string]interface{}) error {
+		for i, op := range ops {
+			if err := applyJSONPatchOperation(doc, op); err != nil {
+				return fmt.Errorf("%w: operation %d (%s %s): %v", ErrInvalidPatch, i, op.Op, op.Path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// patchTask 在事务中读取任务、对字段文档执行 apply、校验并写回
+func patchTask(ctx context.Context, id uint, version uint, apply func(doc map[string]interface{}) error) (dto.TaskDTO, error) {
+	var task models.Task
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 查询任务
+		if err := tx.Where("id = ?", id).First(&task).Error; err != nil {
+			return fmt.Errorf("failed to find task: %w", err)
+		}
+
+		// 校验期望版本
+		if version != 0 && version != task.Version {
+			return &models.VersionConflictError{Current: task}
+		}
+
+		// 补丁中的字段错误与补丁后文档的校验错误合并返回
+		before := task
+		doc := taskPatchDocument(task)
+		fieldErrs := FieldErrors{}
+		if err := apply(doc); err != nil && !errors.As(err, &fieldErrs) {
+			return err
+		}
+		if err := applyTaskPatchDocument(&task, doc); err != nil {
+			var docErrs FieldErrors
+			if !errors.As(err, &docErrs) {
+				return err
+			}
+			for field, msg := range docErrs {
+				if _, ok := fieldErrs[field]; !ok {
+					fieldErrs[field] = msg
+				}
+			}
+		}
+		if len(fieldErrs) > 0 {
+			return fieldErrs
+		}
+
+		// 补丁没有改变任何字段时直接返回当前任务，不增加版本号也不产生变更记录
+		if !patchChanged(before, task) {
+			return nil
+		}
+
+		// 更新任务
+		if err := task.Update(tx); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.TaskDTO{}, err
+	}
+
+	return toTaskDTO(task), nil
+}
+
+// patchChanged 判断补丁是否改变了任务的可修改字段
+func patchChanged(before, after models.Task) bool {
+	return before.Title != after.Title ||
+		before.Description != after.Description ||
+		before.Category != after.Category ||
+		before.Color != after.Color ||
+		before.Status != after.Status ||
+		!before.DueDate.Equal(after.DueDate)
+}
+
+// checkPatchField 检查字段是否允许修改，返回错误说明，允许时返回空字符串
+func checkPatchField(field string) string {
+	if readOnlyFields[field] {
+		return "field is read-only"
+	}
+	if !patchableFields[field] {
+		return "unknown field"
+	}
+	return ""
+}
+
+// taskPatchDocument 将任务的可修改字段转换为 JSON 文档，空字符串字段视为不存在
+func taskPatchDocument(task models.Task) map[string]interface{} {
+	doc := map[string]interface{}{
+		"title":    task.Title,
+		"due_date": task.DueDate.Format("2006-01-02T15:04Z"),
+		"status":   task.Status,
+	}
+	if task.Description != "" {
+		doc["description"] = task.Description
+	}
+	if task.Category != "" {
+		doc["category"] = task.Category
+	}
+	if task.Color != "" {
+		doc["color"] = task.Color
+	}
+	return doc
+}
+
+// applyTaskPatchDocument 校验补丁后的 JSON 文档并写回任务模型
+func applyTaskPatchDocument(task *models.Task, doc map[string]interface{}) error {
+	fieldErrs := FieldErrors{}
+
+	str := func(field string, maxLen int, required bool) string {
+		value, ok := doc[field]
+		if !ok {
+			if required {
+				fieldErrs[field] = "field is required and cannot be cleared"
+			}
+			return ""
+		}
+		if value == nil {
+			fieldErrs[field] = "must not be null, use remove to clear the field"
+			return ""
+		}
+		s, ok := value.(string)
+		if !ok {
+			fieldErrs[field] = "must be a string"
+			return ""
+		}
+		if required && strings.TrimSpace(s) == "" {
+			fieldErrs[field] = "must not be empty"
+			return ""
+		}
+		if maxLen > 0 && len([]rune(s)) > maxLen {
+			fieldErrs[field] = fmt.Sprintf("must be at most %d characters", maxLen)
+			return ""
+		}
+		return s
+	}
+
+	title := str("title", 255, true)
+	description := str("description", 0, false)
+	category := str("category", 100, false)
+	color := str("color", 20, false)
+	status := str("status", 0, true)
+	if status != "" && status != models.TaskStatusPending && status != models.TaskStatusCompleted {
+		fieldErrs["status"] = "must be one of pending, completed"
+	}
+
+	var dueDate time.Time
+	if raw := str("due_date", 0, true); raw != "" {
+		var err error
+		if dueDate, err = time.Parse("2006-01-02T15:04Z", raw); err != nil {
+			fieldErrs["due_date"] = "invalid format, expected yyyy-MM-ddTHH:mmZ"
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	task.Title = title
+	task.Description = description
+	task.Category = category
+	task.Color = color
+	task.Status = status
+	task.DueDate = dueDate
+	return nil
+}
+
+// applyJSONPatchOperation 对 JSON 文档执行单个 JSON Patch 操作
+func applyJSONPatchOperation(doc map[string]interface{}, op dto.JSONPatchOperation) error {
+	field, err := patchPathField(op.Path)
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "add", "replace":
+		if len(op.Value) == 0 {
+			return errors.New("value is required")
+		}
+		if op.Op == "replace" {
+			if _, ok := doc[field]; !ok {
+				return errors.New("path does not exist")
+			}
+		}
+		var value interface{}
+		if err = json.Unmarshal(op.Value, &value); err != nil {
+			return errors.New("invalid value")
+		}
+		// 与 Merge Patch 不同，JSON Patch 中的 null 是普通的值而不是删除，由文档校验拒绝
+		doc[field] = value
+	case "remove":
+		if _, ok := doc[field]; !ok {
+			return errors.New("path does not exist")
+		}
+		delete(doc, field)
+	case "test":
+		if len(op.Value) == 0 {
+			return errors.New("value is required")
+		}
+		var expected interface{}
+		if err = json.Unmarshal(op.Value, &expected); err != nil {
+			return errors.New("invalid value")
+		}
+		if !reflect.DeepEqual(doc[field], expected) {
+			return errors.New("test failed")
+		}
+	case "copy", "move":
+		from, err := patchPathField(op.From)
+		if err != nil {
+			return fmt.Errorf("from: %v", err)
+		}
+		value, ok := doc[from]
+		if !ok {
+			return errors.New("from path does not exist")
+		}
+		if op.Op == "move" {
+			delete(doc, from)
+		}
+		doc[field] = value
+	default:
+		return fmt.Errorf("unsupported operation %q", op.Op)
+	}
+
+	return nil
+}
+
+// patchPathField 解析 JSON Pointer，仅支持指向可修改顶层字段的路径，如 /title
+func patchPathField(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") || strings.Count(path, "/") != 1 {
+		return "", errors.New("path must point to a top-level field")
+	}
+	field := strings.NewReplacer("~1", "/", "~0", "~").Replace(path[1:])
+	if msg := checkPatchField(field); msg != "" {
+		return "", errors.New(msg)
+	}
+	return field, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestPatchWithoutChangesKeepsVersion 空补丁或不改变任何字段的补丁直接返回当前任务，不增加版本号也不记录操作
+func TestPatchWithoutChangesKeepsVersion(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", Color: "red", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := latestOperation(t)
+
+	patches := []struct {
+		name  string
+		apply func() (dto.TaskDTO, error)
+	}{
+		{"empty merge patch", func() (dto.TaskDTO, error) { return MergePatchTask(ctx, task.ID, task.Version, []byte(`{}`)) }},
+		{"empty JSON patch", func() (dto.TaskDTO, error) { return JSONPatchTask(ctx, task.ID, task.Version, []byte(`[]`)) }},
+		{"same values", func() (dto.TaskDTO, error) {
+			return MergePatchTask(ctx, task.ID, task.Version, []byte(`{"title":"a","color":"red"}`))
+		}},
+		{"test only", func() (dto.TaskDTO, error) {
+			return JSONPatchTask(ctx, task.ID, task.Version, []byte(`[{"op":"test","path":"/title","value":"a"}]`))
+		}},
+	}
+	for _, p := range patches {
+		got, err := p.apply()
+		if err != nil {
+			t.Fatalf("%s: %v", p.name, err)
+		}
+		if got.Version != task.Version || got.Title != "a" || got.Color != "red" {
+			t.Errorf("%s: got %+v, want version %d unchanged", p.name, got, task.Version)
+		}
+	}
+	if latest := latestOperation(t); latest.ID != before.ID {
+		t.Errorf("no-op patch recorded operation %+v", latest)
+	}
+}
+
+// TestJSONPatchRejectsNull JSON Patch 中 add/replace 的值为 null 时返回字段错误，而不是清空字段
+func TestJSONPatchRejectsNull(t *testing.T) {
+	testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", Color: "red", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, patch := range []string{
+		`[{"op":"replace","path":"/color","value":null}]`,
+		`[{"op":"replace","path":"/title","value":null}]`,
+		`[{"op":"add","path":"/description","value":null}]`,
+	} {
+		_, err := JSONPatchTask(ctx, task.ID, task.Version, []byte(patch))
+		var fieldErrs FieldErrors
+		if !errors.As(err, &fieldErrs) || len(fieldErrs) != 1 {
+			t.Errorf("%s: got %v, want one field error", patch, err)
+		}
+	}
+
+	got, err := GetTask(task.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != task.Version || got.Color != "red" {
+		t.Errorf("task changed to %+v", got)
+	}
+
+	// remove 仍可清空非必填字段
+	if patched, err := JSONPatchTask(ctx, task.ID, task.Version, []byte(`[{"op":"remove","path":"/color"}]`)); err != nil || patched.Color != "" {
+		t.Errorf("remove color: %+v %v", patched, err)
+	}
+}
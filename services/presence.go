@@ -0,0 +1,186 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// presenceEntry 某个会话在任务上的状态
+type presenceEntry struct {
+	state string
+	since time.Time
+}
+
+// PresenceSession 一个客户端连接的在线状态，同一用户的多个连接互不影响
+type PresenceSession struct {
+	user string
+}
+
+// presenceSubscriber 在线状态的订阅者
+type presenceSubscriber struct {
+	viewer string
+	ch     chan dto.PresenceUpdate
+}
+
+// presenceHub 记录各任务的在线用户并推送变化
+// 在线状态只保存在当前进程内，多实例部署时各实例只能看到连接到本实例的用户
+type presenceHub struct {
+	mu          sync.Mutex
+	tasks       map[uint]map[*PresenceSession]presenceEntry
+	audiences   map[uint][]string // 有在线用户的任务的可见范围，在设置状态时更新
+	subscribers map[*presenceSubscriber]struct{}
+}
+
+var presence = &presenceHub{
+	tasks:       map[uint]map[*PresenceSession]presenceEntry{},
+	audiences:   map[uint][]string{},
+	subscribers: map[*presenceSubscriber]struct{}{},
+}
+
+// JoinPresence 创建在线状态会话
+func JoinPresence(user string) *PresenceSession {
+	return &PresenceSession{user: user}
+}
+
+// visibleTaskAudience 返回 viewer 可见的任务的可见范围，任务不存在或对 viewer 不可见时返回 ErrTaskNotFound
+func visibleTaskAudience(viewer string, taskID uint) ([]string, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return nil, err
+	}
+	audience, err := models.TaskAudience(config.DB, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task members: %w", err)
+	}
+	if !audienceIncludes(audience, viewer) {
+		return nil, fmt.Errorf("%w: %d", ErrTaskNotFound, taskID)
+	}
+	return audience, nil
+}
+
+// Set 设置会话在任务上的状态，state 为空表示离开该任务
+// 任务不存在或对会话的用户不可见时返回 ErrTaskNotFound
+func (s *PresenceSession) Set(taskID uint, state string) error {
+	var audience []string
+	if state != "" {
+		var err error
+		if audience, err = visibleTaskAudience(s.user, taskID); err != nil {
+			return err
+		}
+	}
+
+	presence.mu.Lock()
+	defer presence.mu.Unlock()
+
+	sessions := presence.tasks[taskID]
+	current, ok := sessions[s]
+	if state == "" {
+		if ok {
+			delete(sessions, s)
+			presence.broadcast(taskID)
+		}
+		return nil
+	}
+
+	presence.audiences[taskID] = audience
+	if ok && current.state == state {
+		return nil
+	}
+	if sessions == nil {
+		sessions = map[*PresenceSession]presenceEntry{}
+		presence.tasks[taskID] = sessions
+	}
+	sessions[s] = presenceEntry{state: state, since: time.Now()}
+	presence.broadcast(taskID)
+	return nil
+}
+
+// Leave 离开所有任务，连接断开时调用
+func (s *PresenceSession) Leave() {
+	presence.mu.Lock()
+	defer presence.mu.Unlock()
+
+	for taskID, sessions := range presence.tasks {
+		if _, ok := sessions[s]; !ok {
+			continue
+		}
+		delete(sessions, s)
+		presence.broadcast(taskID)
+	}
+}
+
+// TaskPresence 返回 viewer 可见的任务当前的在线用户，任务不存在或不可见时返回 ErrTaskNotFound
+func TaskPresence(viewer string, taskID uint) (dto.PresenceUpdate, error) {
+	if _, err := visibleTaskAudience(viewer, taskID); err != nil {
+		return dto.PresenceUpdate{}, err
+	}
+
+	presence.mu.Lock()
+	defer presence.mu.Unlock()
+	return presence.snapshot(taskID), nil
+}
+
+// SubscribePresence 订阅 viewer 可见的任务的在线状态变化，返回通道和取消订阅函数
+// 订阅者处理过慢时通道会被关闭，与任务事件一致；客户端重连并重新订阅任务时会收到当前的在线用户
+func SubscribePresence(viewer string) (<-chan dto.PresenceUpdate, func()) {
+	sub := &presenceSubscriber{viewer: viewer, ch: make(chan dto.PresenceUpdate, taskEventBuffer)}
+
+	presence.mu.Lock()
+	presence.subscribers[sub] = struct{}{}
+	presence.mu.Unlock()
+
+	return sub.ch, func() {
+		presence.mu.Lock()
+		defer presence.mu.Unlock()
+		if _, ok := presence.subscribers[sub]; ok {
+			delete(presence.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// snapshot 返回任务的在线用户，按进入时间排序，调用方需持有锁
+func (h *presenceHub) snapshot(taskID uint) dto.PresenceUpdate {
+	update := dto.PresenceUpdate{TaskID: taskID, Users: []dto.PresenceUser{}}
+	for session, entry := range h.tasks[taskID] {
+		update.Users = append(update.Users, dto.PresenceUser{
+			User:  session.user,
+			State: entry.state,
+			Since: entry.since.Format(time.RFC3339),
+		})
+	}
+	sort.Slice(update.Users, func(i, j int) bool {
+		if update.Users[i].Since != update.Users[j].Since {
+			return update.Users[i].Since < update.Users[j].Since
+		}
+		return update.Users[i].User < update.Users[j].User
+	})
+	return update
+}
+
+// broadcast 将任务的在线用户推送给可见该任务的订阅者，缓冲区已满的订阅者会被断开；
+// 任务已没有在线用户时同时清理其记录，调用方需持有锁
+func (h *presenceHub) broadcast(taskID uint) {
+	update := h.snapshot(taskID)
+	audience := h.audiences[taskID]
+	for sub := range h.subscribers {
+		if !audienceIncludes(audience, sub.viewer) {
+			continue
+		}
+		select {
+		case sub.ch <- update:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+
+	if len(h.tasks[taskID]) == 0 {
+		delete(h.tasks, taskID)
+		delete(h.audiences, taskID)
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+)
+
+// createPresenceTask 创建一个任务，返回其 ID
+func createPresenceTask(t *testing.T, ctx context.Context) uint {
+	t.Helper()
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task.ID
+}
+
+// TestPresenceClosesSlowSubscriber 缓冲区已满的订阅者被断开，其他订阅者不受影响
+func TestPresenceClosesSlowSubscriber(t *testing.T) {
+	testdb.Open(t)
+	taskID := createPresenceTask(t, WithCaller(context.Background(), "alice", ""))
+
+	slow, unsubscribeSlow := SubscribePresence("bob")
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := SubscribePresence("carol")
+	defer unsubscribeFast()
+
+	session := JoinPresence("alice")
+	defer session.Leave()
+	for i := 0; i <= taskEventBuffer; i++ {
+		state := "viewing"
+		if i%2 == 1 {
+			state = "editing"
+		}
+		if err := session.Set(taskID, state); err != nil {
+			t.Fatal(err)
+		}
+		<-fast
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != taskEventBuffer {
+		t.Errorf("slow subscriber received %d updates before being closed, want %d", received, taskEventBuffer)
+	}
+
+	// 断开后重新订阅可取得当前的在线用户
+	update, err := TaskPresence("bob", taskID)
+	if err != nil || len(update.Users) != 1 || update.Users[0].User != "alice" || update.Users[0].State != "viewing" {
+		t.Errorf("unexpected presence %+v, %v", update, err)
+	}
+}
+
+// TestPresenceVisibility 只有成员可见的任务的在线状态不推送给其他用户，不存在或不可见的任务不能设置和查询在线状态
+func TestPresenceVisibility(t *testing.T) {
+	testdb.Open(t)
+	alice := WithCaller(context.Background(), "alice", "")
+	private := createPresenceTask(t, alice)
+	if _, err := AddTaskMember(alice, private, RoleAssignee, dto.AddTaskMemberReq{}); err != nil {
+		t.Fatal(err)
+	}
+
+	aliceUpdates, unsubscribeAlice := SubscribePresence("alice")
+	defer unsubscribeAlice()
+	bobUpdates, unsubscribeBob := SubscribePresence("bob")
+	defer unsubscribeBob()
+
+	bob := JoinPresence("bob")
+	defer bob.Leave()
+	if err := bob.Set(private, "viewing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("bob set presence on a members-only task: %v", err)
+	}
+	if err := bob.Set(private+100, "viewing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("bob set presence on a missing task: %v", err)
+	}
+	if _, err := TaskPresence("bob", private); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("bob read presence of a members-only task: %v", err)
+	}
+
+	session := JoinPresence("alice")
+	if err := session.Set(private, "editing"); err != nil {
+		t.Fatal(err)
+	}
+	session.Leave()
+	for _, want := range []int{1, 0} {
+		if update := <-aliceUpdates; len(update.Users) != want {
+			t.Errorf("alice received %+v, want %d users", update, want)
+		}
+	}
+	select {
+	case update := <-bobUpdates:
+		t.Errorf("bob received presence of a members-only task: %+v", update)
+	default:
+	}
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	reminderBatchSize  = 100              // 每次发送的最大提醒数
+	reminderClaimLease = time.Minute      // 发送占用时长，实例崩溃时超时后由其他实例重新发送
+	reminderRetryBase  = 30 * time.Second // 首次重试的等待时间，之后每次翻倍
+	reminderRetryMax   = 30 * time.Minute // 重试等待时间的上限
+	reminderErrorSize  = 1000             // 保存的错误信息最大长度
+)
+
+// ErrReminderNotFound 提醒不存在
+var ErrReminderNotFound = errors.New("reminder not found")
+
+// ReminderChannel 提醒的发送渠道，如站内通知、日志
+// 每个渠道发送成功后单独记录，重试时只发送给失败的渠道；渠道发送成功、记录前实例崩溃时会重复发送，实现需能处理重复的提醒
+type ReminderChannel func(notice dto.ReminderNotice) error
+
+// reminderChannel 已注册的提醒渠道，send 与 sendTx 二选一
+// sendTx 在记录发送成功的事务中发送，如写入数据库的站内通知，不会重复发送
+type reminderChannel struct {
+	send   ReminderChannel
+	sendTx func(tx *gorm.DB, notice dto.ReminderNotice) error
+}
+
+// reminderChannels 已注册的提醒渠道，key 为名称
+var reminderChannels = map[string]reminderChannel{}
+
+// errReminderNotClaimed 提醒的占用已超时并被其他实例占用，由该实例继续发送
+var errReminderNotClaimed = errors.New("reminder claimed by another instance")
+
+// RegisterReminderChannel 注册提醒渠道，需在 StartReminderScheduler 之前调用，通常在 init 中调用
+// 名称中不能包含逗号
+func RegisterReminderChannel(name string, channel ReminderChannel) {
+	registerReminderChannel(name, reminderChannel{send: channel})
+}
+
+// registerReminderChannel 注册提醒渠道
+func registerReminderChannel(name string, channel reminderChannel) {
+	if _, ok := reminderChannels[name]; ok {
+		panic(fmt.Sprintf("reminder channel %q already registered", name))
+	}
+	if strings.Contains(name, ",") {
+		panic(fmt.Sprintf("invalid reminder channel name %q", name))
+	}
+	reminderChannels[name] = channel
+}
+
+func init() {
+	registerReminderChannel("notifications", reminderChannel{sendTx: notifyReminder})
+	RegisterTaskExpander("reminders", func(task models.Task) (interface{}, error) {
+		return fetchReminders(config.DB, task.ID)
+	})
+}
+
+// LogReminderChannel 将提醒写入日志的渠道
+func LogReminderChannel(notice dto.ReminderNotice) error {
+	log.Printf("Reminder %d: task %d %q due %s", notice.ReminderID, notice.Task.ID, notice.Task.Title, notice.Task.DueDate)
+	return nil
+}
+
+// notifyReminder 在事务中为提醒的每个接收人创建站内通知
+func notifyReminder(tx *gorm.DB, notice dto.ReminderNotice) error {
+	text := notice.Note
+	if text == "" {
+		text = notice.Task.Title
+	}
+	for _, recipient := range notice.Recipients {
+		notification := models.Notification{
+			Recipient: recipient,
+			Type:      models.NotificationReminder,
+			TaskID:    notice.Task.ID,
+			Actor:     systemActor,
+			Excerpt:   text,
+		}
+		if err := notification.Create(tx); err != nil {
+			return fmt.Errorf("failed to notify %s: %w", recipient, err)
+		}
+	}
+	return nil
+}
+
+// FetchReminders 按触发时间获取任务的提醒，任务在回收站中时不可见
+func FetchReminders(taskID uint) ([]dto.ReminderDTO, error) {
+	if err := findActiveTask(config.DB, taskID); err != nil {
+		return nil, err
+	}
+	return fetchReminders(config.DB, taskID)
+}
+
+// CreateReminder 为任务添加提醒，提醒时间需晚于当前时间
+func CreateReminder(ctx context.Context, taskID uint, req dto.CreateReminderReq) (dto.ReminderDTO, error) {
+	if (req.RemindAt == "") == (req.OffsetMinutes == nil) {
+		return dto.ReminderDTO{}, FieldErrors{"remind_at": "exactly one of remind_at and offset_minutes is required"}
+	}
+	reminder := models.Reminder{TaskID: taskID, OffsetMinutes: req.OffsetMinutes, Note: req.Note}
+	if req.RemindAt != "" {
+		remindAt, err := time.Parse("2006-01-02T15:04Z", req.RemindAt)
+		if err != nil {
+			return dto.ReminderDTO{}, FieldErrors{"remind_at": "invalid format, expected yyyy-MM-ddTHH:mmZ"}
+		}
+		reminder.RemindAt = &remindAt
+		reminder.FireAt = remindAt
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定任务，避免与截止时间的修改交错
+		var task models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", ErrTaskNotFound, taskID)
+			}
+			return fmt.Errorf("failed to find task: %w", err)
+		}
+		field := "remind_at"
+		if reminder.OffsetMinutes != nil {
+			field = "offset_minutes"
+			reminder.FireAt = task.DueDate.Add(time.Duration(*reminder.OffsetMinutes) * time.Minute)
+		}
+		if !reminder.FireAt.After(time.Now()) {
+			return FieldErrors{field: "reminder time must be in the future"}
+		}
+		if err := reminder.Create(tx); err != nil {
+			return fmt.Errorf("failed to create reminder: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ReminderDTO{}, err
+	}
+	return toReminderDTO(reminder), nil
+}
+
+// DeleteReminder 删除任务的提醒
+func DeleteReminder(ctx context.Context, taskID, id uint) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findActiveTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID); err != nil {
+			return err
+		}
+		var reminder models.Reminder
+		if err := reminder.FetchByID(tx, taskID, id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", ErrReminderNotFound, id)
+			}
+			return fmt.Errorf("failed to find reminder: %w", err)
+		}
+		if err := reminder.Delete(tx); err != nil {
+			return fmt.Errorf("failed to delete reminder: %w", err)
+		}
+		return nil
+	})
+}
+
+// StartReminderScheduler 启动后台任务，按 interval 周期发送到期的提醒
+// 提醒保存在数据库中，进程重启后到期未发送的提醒会继续发送；多个实例可同时运行，每个提醒由占用它的实例发送
+// 触发时任务已完成、在回收站中或已删除的提醒不发送
+func StartReminderScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := dispatchReminders(); err != nil {
+				log.Printf("Reminder scheduler: %v", err)
+			}
+		}
+	}()
+}
+
+// dispatchReminders 发送一批到期的提醒
+func dispatchReminders() error {
+	var model models.Reminder
+	reminders, err := model.FetchDue(config.DB, time.Now(), reminderBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch due reminders: %w", err)
+	}
+
+	for i := range reminders {
+		reminder := reminders[i]
+		// 占用期限从占用时开始计算，前面的提醒发送较慢时不会缩短
+		claimedAt := time.Now()
+		claimed, err := reminder.Claim(config.DB, claimedAt, claimedAt.Add(reminderClaimLease))
+		if err != nil {
+			return fmt.Errorf("failed to claim reminder %d: %w", reminder.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+		if err = dispatchReminder(&reminder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatchReminder 发送已占用的提醒，并记录发送结果；占用超时后被其他实例占用时不再记录
+func dispatchReminder(reminder *models.Reminder) error {
+	notice, ok, err := buildReminderNotice(*reminder)
+	if err == nil && !ok {
+		if _, err = reminder.Finish(config.DB, models.ReminderSkipped, time.Now()); err != nil {
+			return fmt.Errorf("failed to skip reminder %d: %w", reminder.ID, err)
+		}
+		return nil
+	}
+	if err == nil {
+		err = sendReminder(reminder, notice)
+	}
+	if errors.Is(err, errReminderNotClaimed) {
+		log.Printf("Reminder scheduler: reminder %d: %v", reminder.ID, err)
+		return nil
+	}
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > reminderErrorSize {
+			msg = msg[:reminderErrorSize]
+		}
+		attempts := reminder.Attempts + 1
+		retryAt := time.Now().Add(reminderBackoff(attempts))
+		if _, markErr := reminder.MarkFailed(config.DB, msg, retryAt, attempts >= config.ReminderMaxAttempts()); markErr != nil {
+			return fmt.Errorf("failed to record failure for reminder %d: %w", reminder.ID, markErr)
+		}
+		log.Printf("Reminder scheduler: reminder %d: %v", reminder.ID, err)
+		return nil
+	}
+
+	if _, err = reminder.Finish(config.DB, models.ReminderSent, time.Now()); err != nil {
+		return fmt.Errorf("failed to mark reminder %d as sent: %w", reminder.ID, err)
+	}
+	return nil
+}
+
+// buildReminderNotice 构造提醒内容，任务已完成、在回收站中或已删除时返回 false
+func buildReminderNotice(reminder models.Reminder) (dto.ReminderNotice, bool, error) {
+	var task models.Task
+	if err := config.DB.First(&task, reminder.TaskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ReminderNotice{}, false, nil
+		}
+		return dto.ReminderNotice{}, false, fmt.Errorf("failed to find task: %w", err)
+	}
+	if task.Status != models.TaskStatusPending {
+		return dto.ReminderNotice{}, false, nil
+	}
+
+	members, err := fetchTaskMembers(config.DB, task.ID)
+	if err != nil {
+		return dto.ReminderNotice{}, false, err
+	}
+	var recipients []string
+	seen := map[string]bool{"": true, systemActor: true}
+	for _, users := range [][]string{members.Assignees, members.Watchers, {reminder.CreatedBy}} {
+		for _, user := range users {
+			if !seen[user] {
+				seen[user] = true
+				recipients = append(recipients, user)
+			}
+		}
+	}
+
+	return dto.ReminderNotice{
+		ReminderID: reminder.ID,
+		Task:       toTaskDTO(task),
+		FireAt:     reminder.FireAt.Format(time.RFC3339),
+		Note:       reminder.Note,
+		Recipients: recipients,
+	}, true, nil
+}
+
+// sendReminder 将提醒发送给尚未发送成功的渠道，每个渠道成功后单独记录，返回各渠道的错误
+func sendReminder(reminder *models.Reminder, notice dto.ReminderNotice) error {
+	names := make([]string, 0, len(reminderChannels))
+	for name := range reminderChannels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		if reminder.ChannelSent(name) {
+			continue
+		}
+		err := sendReminderTo(reminder, name, reminderChannels[name], notice)
+		if errors.Is(err, errReminderNotClaimed) {
+			return err
+		}
+		if err != nil {
+			failed = append(failed, name+": "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to send to %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// sendReminderTo 将提醒发送到一个渠道，并记录该渠道已发送
+func sendReminderTo(reminder *models.Reminder, name string, channel reminderChannel, notice dto.ReminderNotice) error {
+	if channel.sendTx != nil {
+		// 记录失败时回滚，已回滚的发送不会生效；SentChannels 在提交后才更新
+		sent := reminder.SentChannels
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := channel.sendTx(tx, notice); err != nil {
+				return err
+			}
+			ok, err := reminder.MarkChannelSent(tx, name)
+			if err == nil && !ok {
+				err = errReminderNotClaimed
+			}
+			return err
+		})
+		if err != nil {
+			reminder.SentChannels = sent
+		}
+		return err
+	}
+
+	if err := channel.send(notice); err != nil {
+		return err
+	}
+	ok, err := reminder.MarkChannelSent(config.DB, name)
+	if err != nil {
+		return fmt.Errorf("failed to record reminder %d sent to %s: %w", reminder.ID, name, err)
+	}
+	if !ok {
+		return errReminderNotClaimed
+	}
+	return nil
+}
+
+// reminderBackoff 第 attempt 次发送失败后的重试等待时间
+func reminderBackoff(attempt int) time.Duration {
+	backoff := reminderRetryBase
+	for i := 1; i < attempt && backoff < reminderRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > reminderRetryMax {
+		backoff = reminderRetryMax
+	}
+	return backoff
+}
+
+// fetchReminders 查询任务的全部提醒
+func fetchReminders(db *gorm.DB, taskID uint) ([]dto.ReminderDTO, error) {
+	var model models.Reminder
+	reminders, err := model.FetchByTask(db, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reminders: %w", err)
+	}
+	resp := make([]dto.ReminderDTO, 0, len(reminders))
+	for _, reminder := range reminders {
+		resp = append(resp, toReminderDTO(reminder))
+	}
+	return resp, nil
+}
+
+// toReminderDTO 将提醒模型转换为 ReminderDTO
+func toReminderDTO(r models.Reminder) dto.ReminderDTO {
+	resp := dto.ReminderDTO{
+		ID:            r.ID,
+		TaskID:        r.TaskID,
+		OffsetMinutes: r.OffsetMinutes,
+		FireAt:        r.FireAt.Format(time.RFC3339),
+		Note:          r.Note,
+		Status:        r.Status,
+		CreatedBy:     r.CreatedBy,
+		CreatedAt:     r.CreatedAt.Format(time.RFC3339),
+	}
+	if r.RemindAt != nil {
+		remindAt := r.RemindAt.Format(time.RFC3339)
+		resp.RemindAt = &remindAt
+	}
+	if r.FiredAt != nil {
+		firedAt := r.FiredAt.Format(time.RFC3339)
+		resp.FiredAt = &firedAt
+	}
+	return resp
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// setupReminder 创建负责人为 bob 的任务，并添加一个已到期的提醒
+func setupReminder(t *testing.T) models.Reminder {
+	db := testdb.Open(t)
+	ctx := WithCaller(context.Background(), "alice", "")
+	task, err := CreateTask(ctx, dto.CreateTaskReq{Title: "a", DueDate: "2030-01-01T00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AddTaskMember(ctx, task.ID, models.TaskMemberAssignee, dto.AddTaskMemberReq{User: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	reminder := models.Reminder{TaskID: task.ID, FireAt: time.Now().Add(-time.Minute), Status: models.ReminderPending, CreatedBy: "alice"}
+	if err = db.Create(&reminder).Error; err != nil {
+		t.Fatal(err)
+	}
+	return reminder
+}
+
+// registerTestChannel 注册测试用的提醒渠道，前 failures 次发送失败，返回发送次数
+func registerTestChannel(t *testing.T, failures int) *int {
+	calls := new(int)
+	RegisterReminderChannel("test", func(notice dto.ReminderNotice) error {
+		*calls++
+		if *calls <= failures {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	t.Cleanup(func() { delete(reminderChannels, "test") })
+	return calls
+}
+
+// reminderNotifications 查询提醒类型的通知数
+func reminderNotifications(t *testing.T) int64 {
+	var count int64
+	if err := config.DB.Model(&models.Notification{}).Where("type = ?", models.NotificationReminder).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// TestReminderRetriesFailedChannelOnly 一个渠道失败时只重试该渠道，已发送的站内通知不重复
+func TestReminderRetriesFailedChannelOnly(t *testing.T) {
+	reminder := setupReminder(t)
+	calls := registerTestChannel(t, 1)
+
+	if err := dispatchReminders(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.DB.First(&reminder, reminder.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Status != models.ReminderPending || reminder.Attempts != 1 || reminder.SentChannels != "notifications" {
+		t.Fatalf("unexpected reminder after failure %+v", reminder)
+	}
+	// 负责人和提醒的创建者各一条
+	if got := reminderNotifications(t); got != 2 {
+		t.Fatalf("got %d notifications, want 2", got)
+	}
+
+	if err := config.DB.Model(&reminder).Update("claimed_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatchReminders(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.DB.First(&reminder, reminder.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Status != models.ReminderSent || reminder.SentChannels != "notifications,test" {
+		t.Fatalf("unexpected reminder after retry %+v", reminder)
+	}
+	if got := reminderNotifications(t); got != 2 {
+		t.Errorf("got %d notifications after retry, want 2", got)
+	}
+	if *calls != 2 {
+		t.Errorf("test channel called %d times, want 2", *calls)
+	}
+}
+
+// TestReminderLostClaim 占用超时并被其他实例占用后，原实例不再发送和记录
+func TestReminderLostClaim(t *testing.T) {
+	reminder := setupReminder(t)
+	calls := registerTestChannel(t, 0)
+
+	now := time.Now()
+	if claimed, err := reminder.Claim(config.DB, now, now.Add(reminderClaimLease)); err != nil || !claimed {
+		t.Fatalf("claim: %v %v", claimed, err)
+	}
+	if claimed, err := reminder.Claim(config.DB, time.Now(), time.Now().Add(reminderClaimLease)); err != nil || claimed {
+		t.Fatalf("second claim: %v %v", claimed, err)
+	}
+
+	// 其他实例在占用超时后重新占用
+	if err := config.DB.Model(&models.Reminder{}).Where("id = ?", reminder.ID).
+		Update("claimed_until", now.Add(2*reminderClaimLease)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatchReminder(&reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.Reminder
+	if err := config.DB.First(&stored, reminder.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.ReminderPending || stored.SentChannels != "" || stored.Attempts != 0 {
+		t.Fatalf("reminder changed by the instance that lost its claim %+v", stored)
+	}
+	if got := reminderNotifications(t); got != 0 {
+		t.Errorf("got %d notifications, want 0", got)
+	}
+	if *calls != 0 {
+		t.Errorf("test channel called %d times, want 0", *calls)
+	}
+}
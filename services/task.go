@@ -4,16 +4,35 @@ import (
 	"E-Todo/config"
 	"E-Todo/dto"
 	"E-Todo/models"
+	"context"
+	"errors"
 	"fmt"
+	"gorm.io/gorm"
 	"time"
 )
 
-// CreateTask 创建任务
-func CreateTask(req dto.CreateTaskReq) (dto.TaskDTO, error) {
+// CreateTask 创建任务，任务与其变更事件在同一事务中写入
+func CreateTask(ctx context.Context, req dto.CreateTaskReq) (dto.TaskDTO, error) {
+	var task models.Task
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = createTask(tx, req)
+		return err
+	})
+	if err != nil {
+		return dto.TaskDTO{}, err
+	}
+
+	// 构造 TaskDTO
+	return toTaskDTO(task), nil
+}
+
+// createTask 在给定的事务中创建任务
+func createTask(tx *gorm.DB, req dto.CreateTaskReq) (models.Task, error) {
 	// 解析截止日期
 	dueDate, err := time.Parse("2006-01-02T15:04Z", req.DueDate)
 	if err != nil {
-		return dto.TaskDTO{}, fmt.Errorf("invalid due date format: %w", err)
+		return models.Task{}, FieldErrors{"due_date": "invalid format, expected yyyy-MM-ddTHH:mmZ"}
 	}
 
 	// 初始化任务模型
@@ -26,26 +45,46 @@ func CreateTask(req dto.CreateTaskReq) (dto.TaskDTO, error) {
 	}
 
 	// 保存到数据库
-	if err = task.Create(); err != nil {
-		return dto.TaskDTO{}, fmt.Errorf("failed to create task: %w", err)
+	if err = task.Create(tx); err != nil {
+		return models.Task{}, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	// 构造 TaskDTO
-	return dto.TaskDTO{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Category:    task.Category,
-		Color:       task.Color,
-		DueDate:     task.DueDate.Format("2006-01-02T15:04Z"),
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-	}, nil
+	return task, nil
 }
 
-// FetchAllTasks 获取所有任务
-func FetchAllTasks(req dto.FetchAllTasksReq) ([]dto.TaskDTO, int64, error) {
+// ConflictingTask 从版本冲突错误中取出服务端当前的任务数据
+func ConflictingTask(err error) (dto.TaskDTO, bool) {
+	var conflict *models.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return dto.TaskDTO{}, false
+	}
+	return toTaskDTO(conflict.Current), true
+}
+
+// toTaskDTO 将任务模型转换为 TaskDTO
+func toTaskDTO(t models.Task) dto.TaskDTO {
+	taskDTO := dto.TaskDTO{
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Category:     t.Category,
+		Color:        t.Color,
+		DueDate:      t.DueDate.Format("2006-01-02T15:04Z"),
+		Status:       t.Status,
+		Version:      t.Version,
+		CommentCount: t.CommentCount,
+		Progress:     checklistProgress(t.ChecklistChecked, t.ChecklistTotal),
+		CreatedAt:    t.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:    t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if t.DeletedAt.Valid {
+		taskDTO.DeletedAt = t.DeletedAt.Time.Format("2006-01-02T15:04:05Z")
+	}
+	return taskDTO
+}
+
+// FetchAllTasks 获取所有任务，负责人和关注者过滤条件中的 me 表示当前调用方
+func FetchAllTasks(ctx context.Context, req dto.FetchAllTasksReq) ([]dto.TaskDTO, int64, error) {
 	params := models.TaskQueryParams{
 		Page:          req.Page,
 		Limit:         req.Limit,
@@ -54,6 +93,21 @@ func FetchAllTasks(req dto.FetchAllTasksReq) ([]dto.TaskDTO, int64, error) {
 		Status:        req.Status,
 		Color:         req.Color,
 		RemainingDays: req.RemainingDays,
+		Sort:          req.Sort,
+	}
+	if req.AsOf != "" {
+		asOf, err := parseAsOf(req.AsOf)
+		if err != nil {
+			return nil, 0, err
+		}
+		params.AsOf = asOf
+	}
+	var err error
+	if params.Assignee, err = resolveMe(ctx, "assignee", req.Assignee); err != nil {
+		return nil, 0, err
+	}
+	if params.Watcher, err = resolveMe(ctx, "watcher", req.Watcher); err != nil {
+		return nil, 0, err
 	}
 
 	var task models.Task
@@ -64,28 +118,97 @@ func FetchAllTasks(req dto.FetchAllTasksReq) ([]dto.TaskDTO, int64, error) {
 
 	var taskDTOs []dto.TaskDTO
 	for _, t := range tasks {
-		taskDTOs = append(taskDTOs, dto.TaskDTO{
-			ID:          t.ID,
-			Title:       t.Title,
-			Description: t.Description,
-			Category:    t.Category,
-			Color:       t.Color,
-			DueDate:     t.DueDate.Format("2006-01-02T15:04Z"),
-			Status:      t.Status,
-		})
+		taskDTOs = append(taskDTOs, toTaskDTO(t))
 	}
 
 	return taskDTOs, total, nil
 }
 
-// UpdateTask 更新任务
-func UpdateTask(req dto.UpdateTaskReq) (dto.TaskDTO, error) {
+// parseAsOf 解析 as_of 参数，支持 RFC 3339 和截止日期的格式，不能晚于当前时间
+func parseAsOf(value string) (time.Time, error) {
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if asOf, err = time.Parse("2006-01-02T15:04Z", value); err != nil {
+			return time.Time{}, FieldErrors{"as_of": "must be in RFC 3339 or yyyy-MM-ddTHH:mmZ format"}
+		}
+	}
+	if asOf.After(time.Now()) {
+		return time.Time{}, FieldErrors{"as_of": "must not be in the future"}
+	}
+	return asOf, nil
+}
+
+// ErrTaskNotFound 任务不存在
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrInvalidExpand expand 参数不合法
+var ErrInvalidExpand = errors.New("invalid expand")
+
+// GetTask 获取单个任务，expand 指定需要一并返回的关联数据
+func GetTask(id uint, expand []string) (dto.TaskDetailDTO, error) {
 	// 初始化任务模型
 	var task models.Task
 
 	// 查询任务
-	if err := config.DB.Where("id = ?", req.ID).First(&task).Error; err != nil {
-		return dto.TaskDTO{}, fmt.Errorf("failed to find task: %w", err)
+	if err := config.DB.Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.TaskDetailDTO{}, fmt.Errorf("%w: %d", ErrTaskNotFound, id)
+		}
+		return dto.TaskDetailDTO{}, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	expanded, err := expandTask(task, expand)
+	if err != nil {
+		return dto.TaskDetailDTO{}, err
+	}
+
+	return dto.TaskDetailDTO{
+		TaskDTO: toTaskDTO(task),
+		Expand:  expanded,
+	}, nil
+}
+
+// findActiveTask 确认任务存在且不在回收站中
+func findActiveTask(db *gorm.DB, taskID uint) error {
+	var task models.Task
+	if err := db.Where("id = ?", taskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %d", ErrTaskNotFound, taskID)
+		}
+		return fmt.Errorf("failed to find task: %w", err)
+	}
+	return nil
+}
+
+// UpdateTask 更新任务，任务与其变更事件在同一事务中写入
+func UpdateTask(ctx context.Context, req dto.UpdateTaskReq) (dto.TaskDTO, error) {
+	var task models.Task
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = updateTask(tx, req)
+		return err
+	})
+	if err != nil {
+		return dto.TaskDTO{}, err
+	}
+
+	// 构造 TaskDTO
+	return toTaskDTO(task), nil
+}
+
+// updateTask 在给定的事务中更新任务
+func updateTask(tx *gorm.DB, req dto.UpdateTaskReq) (models.Task, error) {
+	// 初始化任务模型
+	var task models.Task
+
+	// 查询任务
+	if err := tx.Where("id = ?", req.ID).First(&task).Error; err != nil {
+		return models.Task{}, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	// 校验期望版本
+	if req.Version != 0 && req.Version != task.Version {
+		return models.Task{}, &models.VersionConflictError{Current: task}
 	}
 
 	// 更新字段
@@ -104,7 +227,7 @@ func UpdateTask(req dto.UpdateTaskReq) (dto.TaskDTO, error) {
 	if req.DueDate != "" {
 		dueDate, err := time.Parse("2006-01-02T15:04Z", req.DueDate)
 		if err != nil {
-			return dto.TaskDTO{}, fmt.Errorf("invalid due date format: %w", err)
+			return models.Task{}, FieldErrors{"due_date": "invalid format, expected yyyy-MM-ddTHH:mmZ"}
 		}
 		task.DueDate = dueDate
 	}
@@ -113,33 +236,23 @@ func UpdateTask(req dto.UpdateTaskReq) (dto.TaskDTO, error) {
 	}
 
 	// 更新任务
-	if err := task.Update(); err != nil {
-		return dto.TaskDTO{}, fmt.Errorf("failed to update task: %w", err)
+	if err := task.Update(tx); err != nil {
+		return models.Task{}, fmt.Errorf("failed to update task: %w", err)
 	}
 
-	// 构造 TaskDTO
-	return dto.TaskDTO{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Category:    task.Category,
-		Color:       task.Color,
-		DueDate:     task.DueDate.Format("2006-01-02T15:04Z"),
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-	}, nil
+	return task, nil
 }
 
 // DeleteTask 删除任务
-func DeleteTask(id uint) error {
+func DeleteTask(ctx context.Context, id uint, version uint) error {
 	// 初始化任务模型
 	var task models.Task
 
 	task.ID = id
+	task.Version = version
 
 	// 删除任务
-	if err := task.Delete(); err != nil {
+	if err := config.DB.WithContext(ctx).Transaction(task.Delete); err != nil {
 		return fmt.Errorf("failed to hard delete task with ID %d: %w", id, err)
 	}
 
@@ -147,14 +260,15 @@ func DeleteTask(id uint) error {
 }
 
 // SoftDelete 软删除任务
-func SoftDelete(id uint) error {
+func SoftDelete(ctx context.Context, id uint, version uint) error {
 	// 初始化任务模型
 	var task models.Task
 
 	task.ID = id
+	task.Version = version
 
 	// 软删除任务
-	if err := task.SoftDelete(); err != nil {
+	if err := config.DB.WithContext(ctx).Transaction(task.SoftDelete); err != nil {
 		return fmt.Errorf("failed to soft delete task with ID %d: %w", id, err)
 	}
 
@@ -162,83 +276,106 @@ func SoftDelete(id uint) error {
 }
 
 // RestoreTask 恢复任务
-func RestoreTask(id uint) error {
+func RestoreTask(ctx context.Context, id uint, version uint) error {
 	// 初始化任务模型
 	var task models.Task
 
 	task.ID = id
+	task.Version = version
 
 	// 恢复任务
-	if err := task.Restore(); err != nil {
+	if err := config.DB.WithContext(ctx).Transaction(task.Restore); err != nil {
 		return fmt.Errorf("service: failed to restore task with ID %d: %w", id, err)
 	}
 
 	return nil
 }
 
-func CompleteTask(id uint) error {
+// CompleteTask 完成任务
+func CompleteTask(ctx context.Context, id uint, version uint) error {
 	// 初始化任务模型
 	var task models.Task
 
 	task.ID = id
+	task.Version = version
 
 	// 完成任务
-	if err := task.Complete(); err != nil {
+	if err := config.DB.WithContext(ctx).Transaction(task.Complete); err != nil {
 		return fmt.Errorf("service: failed to complete task with ID %d: %w", id, err)
 	}
 
 	return nil
+}
 
+// toBatchTaskActionResp 将批量操作结果转换为响应参数
+func toBatchTaskActionResp(results []models.BatchItemResult, affected int64) dto.BatchTaskActionResp {
+	resp := dto.BatchTaskActionResp{
+		Results:  make([]dto.BatchItemResult, 0, len(results)),
+		Affected: affected,
+	}
+	for _, r := range results {
+		if r.Result == models.BatchResultOK {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, dto.BatchItemResult{ID: r.ID, Result: r.Result})
+	}
+	return resp
 }
 
 // BatchDeleteTasks 批量删除任务
-func BatchDeleteTasks(req dto.BatchTaskActionReq) interface{} {
+func BatchDeleteTasks(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error) {
 	// 初始化任务模型
 	var task models.Task
 
 	// 批量删除任务
-	if err := task.BatchDelete(req.IDs); err != nil {
-		return fmt.Errorf("failed to batch delete tasks: %w", err)
+	results, affected, err := task.BatchDelete(config.DB.WithContext(ctx), req.IDs, req.Atomic)
+	if err != nil {
+		return toBatchTaskActionResp(results, 0), fmt.Errorf("failed to batch delete tasks: %w", err)
 	}
 
-	return nil
+	return toBatchTaskActionResp(results, affected), nil
 }
 
 // BatchCompleteTasks 批量完成任务
-func BatchCompleteTasks(req dto.BatchTaskActionReq) error {
+func BatchCompleteTasks(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error) {
 	// 初始化任务模型
 	var task models.Task
 
 	// 批量完成任务
-	if err := task.BatchComplete(req.IDs); err != nil {
-		return fmt.Errorf("failed to batch complete tasks: %w", err)
+	results, affected, err := task.BatchComplete(config.DB.WithContext(ctx), req.IDs, req.Atomic)
+	if err != nil {
+		return toBatchTaskActionResp(results, 0), fmt.Errorf("failed to batch complete tasks: %w", err)
 	}
 
-	return nil
+	return toBatchTaskActionResp(results, affected), nil
 }
 
 // BatchSoftDeleteTasks 批量软删除任务
-func BatchSoftDeleteTasks(req dto.BatchTaskActionReq) interface{} {
+func BatchSoftDeleteTasks(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error) {
 	// 初始化任务模型
 	var task models.Task
 
 	// 批量软删除任务
-	if err := task.BatchSoftDelete(req.IDs); err != nil {
-		return fmt.Errorf("failed to batch soft delete tasks: %w", err)
+	results, affected, err := task.BatchSoftDelete(config.DB.WithContext(ctx), req.IDs, req.Atomic)
+	if err != nil {
+		return toBatchTaskActionResp(results, 0), fmt.Errorf("failed to batch soft delete tasks: %w", err)
 	}
 
-	return nil
+	return toBatchTaskActionResp(results, affected), nil
 }
 
 // BatchRestoreTasks 批量恢复任务
-func BatchRestoreTasks(req dto.BatchTaskActionReq) error {
+func BatchRestoreTasks(ctx context.Context, req dto.BatchTaskActionReq) (dto.BatchTaskActionResp, error) {
 	// 初始化任务模型
 	var task models.Task
 
 	// 批量恢复任务
-	if err := task.BatchRestore(req.IDs); err != nil {
-		return fmt.Errorf("failed to batch restore tasks: %w", err)
+	results, affected, err := task.BatchRestore(config.DB.WithContext(ctx), req.IDs, req.Atomic)
+	if err != nil {
+		return toBatchTaskActionResp(results, 0), fmt.Errorf("failed to batch restore tasks: %w", err)
 	}
 
-	return nil
+	return toBatchTaskActionResp(results, affected), nil
 }
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"E-Todo/config"
+	"E-Todo/dto"
+	"E-Todo/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// FetchTrashTasks 获取回收站中的任务
+func FetchTrashTasks(req dto.FetchTrashReq) ([]dto.TaskDTO, int64, error) {
+	var task models.Task
+	tasks, total, err := task.FetchTrash(req.Page, req.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch trash: %w", err)
+	}
+
+	taskDTOs := make([]dto.TaskDTO, 0, len(tasks))
+	for _, t := range tasks {
+		taskDTOs = append(taskDTOs, toTaskDTO(t))
+	}
+
+	return taskDTOs, total, nil
+}
+
+// EmptyTrash 清空回收站
+func EmptyTrash(ctx context.Context) (int64, error) {
+	var task models.Task
+	purged, err := task.EmptyTrash(config.DB.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return purged, nil
+}
+
+// PurgeExpiredTrash 彻底删除软删除时间超过保留天数的任务
+func PurgeExpiredTrash(retentionDays int) (int64, error) {
+	var task models.Task
+	before := time.Now().AddDate(0, 0, -retentionDays)
+	purged, err := task.PurgeDeletedBefore(config.DB.WithContext(systemContext()), before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+	return purged, nil
+}
+
+// StartTrashPurgeJob 启动后台清理任务，按 interval 周期清理超过保留天数的软删除任务
+// retentionDays <= 0 时不启动
+func StartTrashPurgeJob(retentionDays int, interval time.Duration) {
+	if retentionDays <= 0 {
+		log.Println("Trash purge job disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeExpiredTrash(retentionDays)
+			if err != nil {
+				log.Printf("Trash purge job: %v", err)
+			} else if purged > 0 {
+				log.Printf("Trash purge job: purged %d task(s) deleted more than %d day(s) ago", purged, retentionDays)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrWebhookNotFound Webhook 订阅不存在
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound 投递记录不存在
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// CreateWebhook 创建 Webhook 订阅，未指定密钥时自动生成，密钥只在创建时返回
+func CreateWebhook(req dto.CreateWebhookReq) (dto.WebhookDTO, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return dto.WebhookDTO{}, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return dto.WebhookDTO{}, err
+		}
+	}
+
+	active := req.Active == nil || *req.Active
+	webhook := models.Webhook{URL: req.URL, Secret: secret, Active: active}
+	webhook.SetEvents(req.EventTypes)
+	if err := webhook.Create(); err != nil {
+		return dto.WebhookDTO{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	// Active 为 false 时 GORM 会使用数据库默认值，需显式更新
+	if !active {
+		if err := webhook.Update(map[string]interface{}{"active": false}); err != nil {
+			return dto.WebhookDTO{}, fmt.Errorf("failed to create webhook: %w", err)
+		}
+	}
+
+	webhookDTO := toWebhookDTO(webhook)
+	webhookDTO.Secret = secret
+	return webhookDTO, nil
+}
+
+// FetchWebhooks 获取全部 Webhook 订阅
+func FetchWebhooks() ([]dto.WebhookDTO, error) {
+	var model models.Webhook
+	webhooks, err := model.FetchAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+
+	webhookDTOs := make([]dto.WebhookDTO, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookDTOs = append(webhookDTOs, toWebhookDTO(webhook))
+	}
+	return webhookDTOs, nil
+}
+
+// GetWebhook 获取单个 Webhook 订阅
+func GetWebhook(id uint) (dto.WebhookDTO, error) {
+	webhook, err := findWebhook(id)
+	if err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	return toWebhookDTO(webhook), nil
+}
+
+// UpdateWebhook 更新 Webhook 订阅，未提供的字段保持不变
+func UpdateWebhook(id uint, req dto.UpdateWebhookReq) (dto.WebhookDTO, error) {
+	webhook, err := findWebhook(id)
+	if err != nil {
+		return dto.WebhookDTO{}, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.URL != "" {
+		if err = validateWebhookURL(req.URL); err != nil {
+			return dto.WebhookDTO{}, err
+		}
+		updates["url"] = req.URL
+	}
+	if req.Secret != "" {
+		updates["secret"] = req.Secret
+	}
+	if len(req.EventTypes) > 0 {
+		var events models.Webhook
+		events.SetEvents(req.EventTypes)
+		updates["event_types"] = events.EventTypes
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+
+	if len(updates) > 0 {
+		if err = webhook.Update(updates); err != nil {
+			return dto.WebhookDTO{}, fmt.Errorf("failed to update webhook: %w", err)
+		}
+		if err = webhook.FetchByID(id); err != nil {
+			return dto.WebhookDTO{}, fmt.Errorf("failed to reload webhook: %w", err)
+		}
+	}
+	return toWebhookDTO(webhook), nil
+}
+
+// DeleteWebhook 删除 Webhook 订阅及其投递记录
+func DeleteWebhook(id uint) error {
+	webhook, err := findWebhook(id)
+	if err != nil {
+		return err
+	}
+	if err = webhook.Delete(); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// FetchWebhookDeliveries 按时间倒序分页获取订阅的投递记录
+func FetchWebhookDeliveries(webhookID uint, req dto.WebhookDeliveriesReq) (dto.WebhookDeliveriesResp, error) {
+	if _, err := findWebhook(webhookID); err != nil {
+		return dto.WebhookDeliveriesResp{}, err
+	}
+
+	var model models.WebhookDelivery
+	deliveries, total, err := model.FetchByWebhook(webhookID, req.Page, req.Limit)
+	if err != nil {
+		return dto.WebhookDeliveriesResp{}, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+
+	resp := dto.WebhookDeliveriesResp{
+		Deliveries: make([]dto.WebhookDeliveryDTO, 0, len(deliveries)),
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toWebhookDeliveryDTO(delivery))
+	}
+	return resp, nil
+}
+
+// RedeliverWebhook 将投递重新加入队列，立即重新投递（包括已成功的投递）
+func RedeliverWebhook(webhookID uint, deliveryID uint64) (dto.WebhookDeliveryDTO, error) {
+	var delivery models.WebhookDelivery
+	if err := delivery.FetchByID(webhookID, deliveryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.WebhookDeliveryDTO{}, fmt.Errorf("%w: %d", ErrWebhookDeliveryNotFound, deliveryID)
+		}
+		return dto.WebhookDeliveryDTO{}, fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+
+	if err := delivery.Redeliver(time.Now()); err != nil {
+		return dto.WebhookDeliveryDTO{}, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	if err := delivery.FetchByID(webhookID, deliveryID); err != nil {
+		return dto.WebhookDeliveryDTO{}, fmt.Errorf("failed to reload webhook delivery: %w", err)
+	}
+	return toWebhookDeliveryDTO(delivery), nil
+}
+
+// findWebhook 根据 ID 查询订阅
+func findWebhook(id uint) (models.Webhook, error) {
+	var webhook models.Webhook
+	if err := webhook.FetchByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Webhook{}, fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+		}
+		return models.Webhook{}, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// validateWebhookURL 只允许 http 和 https 地址
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return FieldErrors{"url": "must be an http or https URL"}
+	}
+	return nil
+}
+
+// generateWebhookSecret 生成随机签名密钥
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// toWebhookDTO 将订阅模型转换为 WebhookDTO，不包含密钥
+func toWebhookDTO(w models.Webhook) dto.WebhookDTO {
+	events := w.Events()
+	if events == nil {
+		events = []string{}
+	}
+	return dto.WebhookDTO{
+		ID:         w.ID,
+		URL:        w.URL,
+		EventTypes: events,
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:  w.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// toWebhookDeliveryDTO 将投递记录转换为 WebhookDeliveryDTO
+func toWebhookDeliveryDTO(d models.WebhookDelivery) dto.WebhookDeliveryDTO {
+	deliveryDTO := dto.WebhookDeliveryDTO{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if d.Status == models.WebhookDeliveryPending {
+		deliveryDTO.NextAttemptAt = d.NextAttemptAt.Format("2006-01-02T15:04:05Z")
+	}
+	if d.DeliveredAt != nil {
+		deliveryDTO.DeliveredAt = d.DeliveredAt.Format("2006-01-02T15:04:05Z")
+	}
+	return deliveryDTO
+}
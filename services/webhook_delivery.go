@@ -0,0 +1,234 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/models"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Webhook 请求头
+const (
+	WebhookEventHeader     = "X-ETodo-Event"     // 事件类型
+	WebhookDeliveryHeader  = "X-ETodo-Delivery"  // 投递 ID，重试时不变，接收方可据此去重
+	WebhookTimestampHeader = "X-ETodo-Timestamp" // 签名时间，Unix 秒
+	WebhookSignatureHeader = "X-ETodo-Signature" // sha256=<HMAC-SHA256(secret, timestamp + "." + body) 的十六进制>
+)
+
+const (
+	webhookTimeout      = 10 * time.Second // 单次投递的超时时间
+	webhookClaimLease   = time.Minute      // 投递占用时长，超时未完成的投递会被重新投递
+	webhookBatchSize    = 100              // 每次处理的最大投递数
+	webhookConcurrency  = 8                // 同时进行的投递数
+	webhookRetryBase    = 30 * time.Second // 首次重试的等待时间，之后每次翻倍
+	webhookRetryMax     = 6 * time.Hour    // 重试等待时间的上限
+	webhookErrorMaxSize = 1000             // 保存的错误信息最大长度
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// SignWebhookPayload 计算推送请求的签名，接收方使用相同方法校验
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueueWebhookDeliveries 为订阅了该事件的 Webhook 创建投递记录，作为发件箱的事件接收方
+// 同一事件重复发布时不会重复加入队列
+func enqueueWebhookDeliveries(event dto.TaskEvent) error {
+	var model models.Webhook
+	webhooks, err := model.FetchActive()
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	var payload []byte
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	var delivery models.WebhookDelivery
+	if err = delivery.Enqueue(deliveries); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterEventSink("webhooks", enqueueWebhookDeliveries)
+}
+
+// StartWebhookDispatcher 启动后台任务，按 interval 周期投递到期的记录
+// 投递失败按指数退避重试，最多尝试 maxAttempts 次
+func StartWebhookDispatcher(interval time.Duration, maxAttempts int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := deliverDueWebhooks(maxAttempts); err != nil {
+				log.Printf("Webhook dispatcher: %v", err)
+			}
+		}
+	}()
+}
+
+// deliverDueWebhooks 投递所有到期的记录
+func deliverDueWebhooks(maxAttempts int) error {
+	var model models.WebhookDelivery
+	deliveries, err := model.FetchDue(time.Now(), webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch due webhook deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	var webhookModel models.Webhook
+	webhooks, err := webhookModel.FetchAll()
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	byID := make(map[uint]models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookConcurrency)
+	for i := range deliveries {
+		delivery := deliveries[i]
+		// 先等待空闲的并发名额再占用，占用期限从实际开始投递时计算，避免排队期间期限已过被其他实例重复投递
+		sem <- struct{}{}
+		claimedAt := time.Now()
+		claimed, err := delivery.Claim(claimedAt, claimedAt.Add(webhookClaimLease))
+		if err != nil {
+			<-sem
+			log.Printf("Webhook dispatcher: failed to claim delivery %d: %v", delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			webhook, ok := byID[delivery.WebhookID]
+			if err := deliverWebhook(webhook, ok, delivery, maxAttempts); err != nil {
+				log.Printf("Webhook dispatcher: delivery %d: %v", delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// deliverWebhook 执行一次投递并记录结果
+func deliverWebhook(webhook models.Webhook, exists bool, delivery models.WebhookDelivery, maxAttempts int) error {
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": delivery.Attempts + 1}
+
+	if !exists || !webhook.Active {
+		// 订阅已停用，不再投递，重新启用后可手动重新投递
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = "webhook is inactive"
+		return delivery.Record(updates)
+	}
+
+	status, err := postWebhook(webhook, delivery, now)
+	updates["response_status"] = status
+	if err == nil {
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["last_error"] = ""
+		updates["delivered_at"] = now
+		return delivery.Record(updates)
+	}
+
+	msg := err.Error()
+	if len(msg) > webhookErrorMaxSize {
+		msg = msg[:webhookErrorMaxSize]
+	}
+	updates["last_error"] = msg
+	if delivery.Attempts+1 >= maxAttempts {
+		updates["status"] = models.WebhookDeliveryFailed
+	} else {
+		updates["next_attempt_at"] = now.Add(webhookBackoff(delivery.Attempts + 1))
+	}
+	return delivery.Record(updates)
+}
+
+// postWebhook 发送签名的推送请求，返回响应状态码；非 2xx 响应视为失败
+func postWebhook(webhook models.Webhook, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "E-Todo-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff 第 attempt 次失败后的重试等待时间
+func webhookBackoff(attempt int) time.Duration {
+	backoff := webhookRetryBase
+	for i := 1; i < attempt && backoff < webhookRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookRetryMax {
+		backoff = webhookRetryMax
+	}
+	return backoff
+}
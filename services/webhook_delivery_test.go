@@ -0,0 +1,187 @@
+package services
+
+import (
+	"E-Todo/dto"
+	"E-Todo/internal/testdb"
+	"E-Todo/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver 本地的 Webhook 接收方，按顺序返回 statuses 中的状态码，并校验签名
+type webhookReceiver struct {
+	t        *testing.T
+	secret   string
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp := r.Header.Get(WebhookTimestampHeader)
+	if got, want := r.Header.Get(WebhookSignatureHeader), SignWebhookPayload(rcv.secret, timestamp, body); got != want {
+		rcv.t.Errorf("signature %q, want %q", got, want)
+	}
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		rcv.t.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// setupWebhook 创建指向本地接收方的订阅，并为一个事件加入投递
+func setupWebhook(t *testing.T, statuses ...int) (*webhookReceiver, dto.WebhookDTO) {
+	testdb.Open(t)
+	rcv := &webhookReceiver{t: t, secret: "s3cret", statuses: statuses}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+
+	webhook, err := CreateWebhook(dto.CreateWebhookReq{URL: srv.URL, Secret: rcv.secret, EventTypes: []string{models.TaskEventCreated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := dto.TaskEvent{ID: 7, Type: models.TaskEventCreated, Task: dto.TaskDTO{ID: 1, Title: "a"}}
+	if err = enqueueWebhookDeliveries(event); err != nil {
+		t.Fatal(err)
+	}
+	// 重复发布的事件不会重复加入队列
+	if err = enqueueWebhookDeliveries(event); err != nil {
+		t.Fatal(err)
+	}
+	return rcv, webhook
+}
+
+// fetchDeliveries 通过投递日志查询订阅的投递记录
+func fetchDeliveries(t *testing.T, webhookID uint) []dto.WebhookDeliveryDTO {
+	resp, err := FetchWebhookDeliveries(webhookID, dto.WebhookDeliveriesReq{Page: 1, Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Deliveries
+}
+
+// TestWebhookDeliverySigned 投递的请求带有正确的签名和事件信息，成功后记录在投递日志中
+func TestWebhookDeliverySigned(t *testing.T) {
+	rcv, webhook := setupWebhook(t)
+
+	if err := deliverDueWebhooks(3); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rcv.requests))
+	}
+	req := rcv.requests[0]
+	if got := req.Header.Get(WebhookEventHeader); got != models.TaskEventCreated {
+		t.Errorf("event header %q", got)
+	}
+	deliveries := fetchDeliveries(t, webhook.ID)
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.Status != models.WebhookDeliverySucceeded || d.Attempts != 1 || d.ResponseStatus != http.StatusOK {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+	if got := req.Header.Get(WebhookDeliveryHeader); got != strconv.FormatUint(d.ID, 10) {
+		t.Errorf("delivery header %q, want %d", got, d.ID)
+	}
+
+	// 已成功的投递不会再次投递
+	if err := deliverDueWebhooks(3); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcv.requests) != 1 {
+		t.Fatalf("receiver got %d requests after success, want 1", len(rcv.requests))
+	}
+}
+
+// TestWebhookDeliveryRetry 非 2xx 响应按退避时间重试，超过最大次数后失败，可手动重新投递
+func TestWebhookDeliveryRetry(t *testing.T) {
+	rcv, webhook := setupWebhook(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	before := time.Now()
+	if err := deliverDueWebhooks(2); err != nil {
+		t.Fatal(err)
+	}
+	var delivery models.WebhookDelivery
+	if err := delivery.FetchByID(webhook.ID, fetchDeliveries(t, webhook.ID)[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError == "" {
+		t.Fatalf("unexpected delivery after failure %+v", delivery)
+	}
+	if retry := delivery.NextAttemptAt.Sub(before); retry < webhookBackoff(1) || retry > webhookBackoff(1)+5*time.Second {
+		t.Errorf("retry scheduled after %v, want about %v", retry, webhookBackoff(1))
+	}
+
+	// 未到重试时间时不投递
+	if err := deliverDueWebhooks(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcv.requests) != 1 {
+		t.Fatalf("receiver got %d requests before the retry is due, want 1", len(rcv.requests))
+	}
+
+	// 到期后重试，达到最大次数后不再重试
+	if err := delivery.Record(map[string]interface{}{"next_attempt_at": time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverDueWebhooks(2); err != nil {
+		t.Fatal(err)
+	}
+	d := fetchDeliveries(t, webhook.ID)[0]
+	if d.Status != models.WebhookDeliveryFailed || d.Attempts != 2 || d.ResponseStatus != http.StatusBadGateway {
+		t.Fatalf("unexpected delivery after the last attempt %+v", d)
+	}
+
+	// 重新投递
+	redelivered, err := RedeliverWebhook(webhook.ID, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivered.Status != models.WebhookDeliveryPending || redelivered.Attempts != 0 {
+		t.Fatalf("unexpected redelivered delivery %+v", redelivered)
+	}
+	if err = deliverDueWebhooks(2); err != nil {
+		t.Fatal(err)
+	}
+	if d = fetchDeliveries(t, webhook.ID)[0]; d.Status != models.WebhookDeliverySucceeded {
+		t.Fatalf("unexpected delivery after redelivery %+v", d)
+	}
+	if len(rcv.requests) != 3 || string(rcv.bodies[0]) != string(rcv.bodies[2]) {
+		t.Errorf("redelivery should resend the same payload")
+	}
+}
+
+// TestWebhookClaimExclusive 已被占用的投递不会被再次占用
+func TestWebhookClaimExclusive(t *testing.T) {
+	_, webhook := setupWebhook(t)
+	var delivery models.WebhookDelivery
+	if err := delivery.FetchByID(webhook.ID, fetchDeliveries(t, webhook.ID)[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if claimed, err := delivery.Claim(now, now.Add(webhookClaimLease)); err != nil || !claimed {
+		t.Fatalf("first claim: %v %v", claimed, err)
+	}
+	if claimed, err := delivery.Claim(time.Now(), time.Now().Add(webhookClaimLease)); err != nil || claimed {
+		t.Fatalf("second claim: %v %v", claimed, err)
+	}
+}
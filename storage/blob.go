@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound 对象不存在
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore 文件内容的存储，key 由调用方生成，只包含字母、数字和 / - _ .
+type BlobStore interface {
+	// Put 写入对象，size 为内容长度，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭，不存在时返回 ErrBlobNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
@@ -1,16 +1,36 @@
 package utils
 
 import (
+	"E-Todo/apperrors"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
+	"strings"
 )
 
 type Response struct {
-	Code int         `json:"code"` // 状态码
-	Msg  string      `json:"msg"`  // 提示信息
-	Data interface{} `json:"data"` // 数据
+	Code   int               `json:"code"`             // 状态码
+	Msg    string            `json:"msg"`              // 提示信息
+	Data   interface{}       `json:"data"`             // 数据
+	Error  string            `json:"error,omitempty"`  // 机器可读的错误名称
+	Fields map[string]string `json:"fields,omitempty"` // 字段级错误
 }
 
+// ProblemDetails RFC 7807 problem+json 错误响应
+type ProblemDetails struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     int               `json:"code"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	Data     interface{}       `json:"data,omitempty"`
+}
+
+// problemJSON problem+json 媒体类型
+const problemJSON = "application/problem+json"
+
 // Success 成功返回
 func Success(c *gin.Context, data interface{}, msg string) {
 	c.JSON(http.StatusOK, Response{
@@ -20,11 +40,51 @@ func Success(c *gin.Context, data interface{}, msg string) {
 	})
 }
 
-// Fail 失败返回
-func Fail(c *gin.Context, data interface{}, code int, msg string) {
-	c.JSON(http.StatusOK, Response{
-		Code: code,
+// Created 创建成功返回
+func Created(c *gin.Context, data interface{}, msg string) {
+	c.JSON(http.StatusCreated, Response{
+		Code: 0,
 		Msg:  msg,
 		Data: data,
 	})
 }
+
+// Error 失败返回，根据错误类型设置 HTTP 状态码和错误码
+// 请求头 Accept 包含 application/problem+json 时以 RFC 7807 格式返回
+func Error(c *gin.Context, err error) {
+	appErr := apperrors.From(err)
+
+	// 内部错误只记录日志，不向客户端暴露细节
+	msg := appErr.Message
+	if appErr.Code == apperrors.CodeInternal {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		msg = apperrors.CodeInternal.Title
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), problemJSON) {
+		c.Render(appErr.Code.Status, problemRender{ProblemDetails{
+			Type:     "urn:e-todo:error:" + appErr.Code.Name,
+			Title:    appErr.Code.Title,
+			Status:   appErr.Code.Status,
+			Detail:   msg,
+			Instance: c.Request.URL.Path,
+			Code:     appErr.Code.Num,
+			Errors:   appErr.Fields,
+			Data:     appErr.Data,
+		}})
+		return
+	}
+
+	c.JSON(appErr.Code.Status, Response{
+		Code:   appErr.Code.Num,
+		Msg:    msg,
+		Data:   appErr.Data,
+		Error:  appErr.Code.Name,
+		Fields: appErr.Fields,
+	})
+}
+
+// BindError 请求参数绑定或校验失败时返回
+func BindError(c *gin.Context, err error) {
+	Error(c, apperrors.Validation(err))
+}